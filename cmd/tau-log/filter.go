@@ -0,0 +1,387 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/ParticleHealth/tau/slog"
+)
+
+// filter matches records against a parsed expression.
+type filter interface {
+	match(r *record) bool
+}
+
+// and matches when both sides match.
+type and struct{ l, r filter }
+
+func (f and) match(r *record) bool { return f.l.match(r) && f.r.match(r) }
+
+// or matches when either side matches.
+type or struct{ l, r filter }
+
+func (f or) match(r *record) bool { return f.l.match(r) || f.r.match(r) }
+
+// not matches when the inner filter does not.
+type not struct{ f filter }
+
+func (f not) match(r *record) bool { return !f.f.match(r) }
+
+// exists matches when the field is set.
+type exists struct{ field string }
+
+func (f exists) match(r *record) bool {
+	_, ok := r.field(f.field)
+	return ok
+}
+
+// compare a field against a value.
+type compare struct {
+	field string
+	op    string
+	value string
+	re    *regexp.Regexp
+}
+
+func (f compare) match(r *record) bool {
+	v, ok := r.field(f.field)
+	switch f.op {
+	case "~":
+		return ok && f.re.MatchString(v)
+	case "!~":
+		return !ok || !f.re.MatchString(v)
+	case "!=":
+		return !ok || v != f.value
+	}
+	if !ok {
+		return false
+	}
+	c := compareValues(f.field, v, f.value)
+	switch f.op {
+	case "=":
+		return c == 0
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	default: // ">="
+		return c >= 0
+	}
+}
+
+// compareValues by severity for the severity field, numerically when both are numbers, and as strings otherwise.
+func compareValues(field, a, b string) int {
+	if field == "severity" {
+		sa, erra := slog.ParseSeverity(a)
+		sb, errb := slog.ParseSeverity(b)
+		if erra == nil && errb == nil {
+			return int(sa) - int(sb)
+		}
+	}
+	fa, erra := strconv.ParseFloat(a, 64)
+	fb, errb := strconv.ParseFloat(b, 64)
+	if erra == nil && errb == nil {
+		switch {
+		case fa < fb:
+			return -1
+		case fa > fb:
+			return 1
+		}
+		return 0
+	}
+	return strings.Compare(a, b)
+}
+
+// fields that can be used in expressions, in addition to labels.<key> and details.<key>.
+var fields = map[string]bool{
+	"severity":  true,
+	"message":   true,
+	"error":     true,
+	"exception": true,
+	"trace":     true,
+	"span":      true,
+	"operation": true,
+	"producer":  true,
+	"file":      true,
+	"function":  true,
+}
+
+// field value of the record as a string, and whether it is set.
+func (r *record) field(name string) (string, bool) {
+	e := &r.Entry
+	var v string
+	switch name {
+	case "severity":
+		return e.Severity.String(), true
+	case "message":
+		v = e.Message
+	case "error":
+		v = e.Err
+	case "exception":
+		v = e.StackTrace
+	case "trace":
+		v = e.Trace
+	case "span":
+		v = e.SpanID
+	case "operation":
+		if e.Operation != nil {
+			v = e.Operation.ID
+		}
+	case "producer":
+		if e.Operation != nil {
+			v = e.Operation.Producer
+		}
+	case "file":
+		if e.SourceLocation != nil {
+			v = e.SourceLocation.File
+		}
+	case "function":
+		if e.SourceLocation != nil {
+			v = e.SourceLocation.Function
+		}
+	default:
+		if k := strings.TrimPrefix(name, "labels."); k != name {
+			v, ok := e.Labels[k]
+			return v, ok
+		}
+		return detail(e.Details, strings.TrimPrefix(name, "details."))
+	}
+	return v, v != ""
+}
+
+// detail at a dotted path, walking into nested objects.
+func detail(details slog.Fields, path string) (string, bool) {
+	var v interface{} = map[string]interface{}(details)
+	for _, k := range strings.Split(path, ".") {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return "", false
+		}
+		if v, ok = m[k]; !ok {
+			return "", false
+		}
+	}
+	return valueString(v), true
+}
+
+// valueString of a decoded JSON value, strings are left unquoted.
+func valueString(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+// parseFilter expression such as `severity >= WARNING and (labels.service = api or details.code ~ "^5")`.
+// Fields are compared with =, !=, <, <=, >, >=, or matched against a regular expression with ~ and !~.
+// A field on its own matches when it is set. Terms are combined with and, or, not and parentheses.
+func parseFilter(s string) (filter, error) {
+	toks, err := lex(s)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{toks: toks}
+	f, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.toks) {
+		return nil, fmt.Errorf("unexpected %q", p.toks[p.pos].text)
+	}
+	return f, nil
+}
+
+// token of a filter expression.
+type token struct {
+	text   string
+	quoted bool
+}
+
+// operators in order of matching, longest first.
+var operators = []string{"!=", "!~", "<=", ">=", "==", "=", "~", "<", ">", "(", ")", "&&", "||", "!"}
+
+// lex an expression into tokens.
+func lex(s string) ([]token, error) {
+	var toks []token
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c == ' ' || c == '\t' || c == '\n' {
+			i++
+			continue
+		}
+		if c == '"' || c == '\'' {
+			j := i + 1
+			for j < len(s) && s[j] != c {
+				if s[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(s) {
+				return nil, fmt.Errorf("unterminated string at %d", i)
+			}
+			text := s[i+1 : j]
+			if c == '"' {
+				var err error
+				if text, err = strconv.Unquote(s[i : j+1]); err != nil {
+					return nil, fmt.Errorf("invalid string at %d: %w", i, err)
+				}
+			}
+			toks = append(toks, token{text: text, quoted: true})
+			i = j + 1
+			continue
+		}
+		op := ""
+		for _, o := range operators {
+			if strings.HasPrefix(s[i:], o) {
+				op = o
+				break
+			}
+		}
+		if op != "" {
+			toks = append(toks, token{text: op})
+			i += len(op)
+			continue
+		}
+		j := i
+		for j < len(s) && isWord(rune(s[j])) {
+			j++
+		}
+		if j == i {
+			return nil, fmt.Errorf("unexpected %q at %d", c, i)
+		}
+		toks = append(toks, token{text: s[i:j]})
+		i = j
+	}
+	return toks, nil
+}
+
+// isWord reports whether r can be part of an unquoted field name or value.
+func isWord(r rune) bool {
+	return r >= 0x80 || unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("._-/:@+*", r)
+}
+
+// parser of filter tokens using recursive descent, with not binding tighter than and, and and tighter than or.
+type parser struct {
+	toks []token
+	pos  int
+}
+
+// accept the next token if it is one of the given keywords or operators.
+func (p *parser) accept(words ...string) bool {
+	if p.pos >= len(p.toks) || p.toks[p.pos].quoted {
+		return false
+	}
+	for _, w := range words {
+		if strings.EqualFold(p.toks[p.pos].text, w) {
+			p.pos++
+			return true
+		}
+	}
+	return false
+}
+
+func (p *parser) or() (filter, error) {
+	l, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("or", "||") {
+		r, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		l = or{l, r}
+	}
+	return l, nil
+}
+
+func (p *parser) and() (filter, error) {
+	l, err := p.not()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("and", "&&") {
+		r, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		l = and{l, r}
+	}
+	return l, nil
+}
+
+func (p *parser) not() (filter, error) {
+	if p.accept("not", "!") {
+		f, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		return not{f}, nil
+	}
+	return p.term()
+}
+
+func (p *parser) term() (filter, error) {
+	if p.accept("(") {
+		f, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if !p.accept(")") {
+			return nil, fmt.Errorf("missing )")
+		}
+		return f, nil
+	}
+	if p.pos >= len(p.toks) {
+		return nil, fmt.Errorf("expected field at end of expression")
+	}
+	tok := p.toks[p.pos]
+	if tok.quoted || !isField(tok.text) {
+		return nil, fmt.Errorf("unknown field %q", tok.text)
+	}
+	p.pos++
+	start := p.pos
+	if !p.accept("!=", "!~", "<=", ">=", "==", "=", "~", "<", ">") {
+		return exists{field: tok.text}, nil
+	}
+	op := p.toks[start].text
+	if op == "==" {
+		op = "="
+	}
+	if p.pos >= len(p.toks) {
+		return nil, fmt.Errorf("expected value after %s %s", tok.text, op)
+	}
+	c := compare{field: tok.text, op: op, value: p.toks[p.pos].text}
+	p.pos++
+	if op == "~" || op == "!~" {
+		re, err := regexp.Compile(c.value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern for %s: %w", tok.text, err)
+		}
+		c.re = re
+	}
+	if tok.text == "severity" && op != "~" && op != "!~" {
+		s, err := slog.ParseSeverity(c.value)
+		if err != nil {
+			return nil, err
+		}
+		c.value = s.String()
+	}
+	return c, nil
+}
+
+// isField reports whether name can be used in an expression.
+func isField(name string) bool {
+	return fields[name] || strings.HasPrefix(name, "labels.") || strings.HasPrefix(name, "details.")
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/ParticleHealth/tau/slog"
+)
+
+func TestFilter(t *testing.T) {
+	r := &record{Entry: slog.Entry{
+		Message:   "fetch failed",
+		Severity:  slog.SeverityError,
+		Err:       "unavailable",
+		Labels:    map[string]string{"service": "api"},
+		Details:   slog.Fields{"status": float64(503), "req": map[string]interface{}{"id": "r1"}},
+		Trace:     "projects/p/traces/t1",
+		Operation: &slog.Operation{ID: "op1", Producer: "job"},
+	}}
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{`severity >= warning`, true},
+		{`severity > error`, false},
+		{`severity = ERROR`, true},
+		{`message = "fetch failed"`, true},
+		{`message ~ '^fetch'`, true},
+		{`message !~ fetch`, false},
+		{`error`, true},
+		{`exception`, false},
+		{`not exception`, true},
+		{`labels.service = api`, true},
+		{`labels.service != api`, false},
+		{`labels.missing != api`, true},
+		{`details.status >= 500 && details.status < 600`, true},
+		{`details.status > 1000`, false},
+		{`details.req.id = r1`, true},
+		{`details.req.missing`, false},
+		{`operation = op1 and producer = job`, true},
+		{`trace ~ t2 or (span or labels.service = api)`, true},
+		{`!(trace ~ t1)`, false},
+	}
+	for _, tt := range tests {
+		f, err := parseFilter(tt.expr)
+		if err != nil {
+			t.Errorf("parsing %s: %v", tt.expr, err)
+			continue
+		}
+		if got := f.match(r); got != tt.want {
+			t.Errorf("unexpected match for %s\nwant: %v\ngot: %v", tt.expr, tt.want, got)
+		}
+	}
+}
+
+func TestFilterErrors(t *testing.T) {
+	for _, expr := range []string{
+		`unknown = 1`,
+		`message =`,
+		`(message`,
+		`message = "unterminated`,
+		`message ~ "("`,
+		`severity >= loud`,
+		`message = a b`,
+	} {
+		if _, err := parseFilter(expr); err == nil {
+			t.Errorf("expected error parsing %s", expr)
+		}
+	}
+}
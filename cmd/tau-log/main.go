@@ -0,0 +1,153 @@
+// Command tau-log pretty-prints, filters and groups JSON logs written by the slog package.
+//
+// Entries are read from the given files, or stdin, as newline delimited JSON or as the array written by
+// gcloud logging read --format=json. For example:
+//
+//	gcloud logging read 'resource.type="cloud_run_revision"' --format=json | tau-log -severity warning
+//	tau-log -filter 'labels.service = api and details.status >= 500' -group trace app.log
+//
+// Filter expressions compare fields with =, !=, <, <=, >, >=, or match a regular expression with ~ and !~.
+// Fields are severity, message, error, exception, trace, span, operation, producer, file, function,
+// labels.<key> and details.<key>, where nested details are separated by dots. A field on its own matches
+// when it is set. Terms are combined with and, or, not and parentheses, and values with spaces are quoted.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ParticleHealth/tau/slog"
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, "tau-log:", err)
+		}
+		os.Exit(2)
+	}
+}
+
+// options parsed from the command line.
+type options struct {
+	filter   filter
+	severity slog.Severity
+	group    string
+	json     bool
+	files    []string
+}
+
+// matches reports whether a record should be written. Text is only written when nothing is filtered.
+func (o *options) matches(r *record) bool {
+	if r.text {
+		return o.filter == nil && o.severity == slog.SeverityDefault
+	}
+	if r.Severity < o.severity {
+		return false
+	}
+	return o.filter == nil || o.filter.match(r)
+}
+
+// run the command with the given arguments, reading stdin when no files are given.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("tau-log", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: tau-log [flags] [file ...]")
+		fs.PrintDefaults()
+	}
+	var o options
+	expr := fs.String("filter", "", "only show entries matching the expression, such as 'severity >= error and labels.service = api'")
+	fs.Var(&o.severity, "severity", "only show entries at or above the severity, such as warning")
+	fs.StringVar(&o.group, "group", "", "group entries by operation or trace")
+	fs.BoolVar(&o.json, "json", false, "write matching entries as JSON instead of rendering them")
+	stack := fs.Bool("stack", true, "show stack traces")
+	color := fs.Bool("color", isTerminal(stdout), "color output")
+	utc := fs.Bool("utc", false, "show timestamps in UTC rather than local time")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	o.files = fs.Args()
+	if *expr != "" {
+		f, err := parseFilter(*expr)
+		if err != nil {
+			return fmt.Errorf("invalid filter: %w", err)
+		}
+		o.filter = f
+	}
+	if o.group != "" && o.group != "operation" && o.group != "trace" {
+		return fmt.Errorf("invalid group %q, must be operation or trace", o.group)
+	}
+
+	p := &renderer{w: stdout, color: *color && !o.json, stack: *stack, utc: *utc}
+	write := p.render
+	if o.json {
+		write = func(r *record) { fmt.Fprintf(stdout, "%s\n", r.raw) }
+	}
+	var g *grouper
+	if o.group != "" {
+		g = &grouper{by: o.group, index: make(map[string]*group)}
+	}
+	fn := func(r *record) {
+		if !o.matches(r) {
+			return
+		}
+		if g != nil {
+			g.add(r)
+			return
+		}
+		write(r)
+	}
+
+	if len(o.files) == 0 {
+		if err := read(stdin, fn); err != nil {
+			return err
+		}
+	}
+	for _, name := range o.files {
+		if err := readFile(name, fn); err != nil {
+			return err
+		}
+	}
+	if g != nil {
+		if o.json {
+			for _, grp := range g.groups {
+				for _, r := range grp.records {
+					write(r)
+				}
+			}
+		} else {
+			g.render(p)
+		}
+	}
+	return nil
+}
+
+// readFile of records, with - meaning stdin.
+func readFile(name string, fn func(*record)) error {
+	if name == "-" {
+		return read(os.Stdin, fn)
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := read(f, fn); err != nil {
+		return fmt.Errorf("reading %s: %w", name, err)
+	}
+	return nil
+}
+
+// isTerminal reports whether w is a character device such as a terminal.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const input = `{"message":"starting","severity":"INFO","logging.googleapis.com/labels":{"service":"api"},"time":"2026-10-18T12:00:00Z"}
+not json
+{"message":"fetch failed","severity":"ERROR","error":"unavailable","details":{"status":503},"logging.googleapis.com/operation":{"id":"op1","producer":"job"},"exception":"unavailable\n\ngoroutine 1 [running]:\nmain.main()\n"}
+{"message":"retrying","severity":"WARNING","logging.googleapis.com/operation":{"id":"op1","producer":"job","last":true}}
+`
+
+func runCommand(t *testing.T, in string, args ...string) string {
+	t.Helper()
+	var out, errs bytes.Buffer
+	if err := run(args, strings.NewReader(in), &out, &errs); err != nil {
+		t.Fatalf("running with %v: %v\n%s", args, err, errs.String())
+	}
+	return out.String()
+}
+
+func TestRender(t *testing.T) {
+	got := runCommand(t, input, "-utc", "-color=false")
+	for _, want := range []string{
+		"2026-10-18T12:00:00.000Z INFO      starting\n  labels: service=api\n",
+		"not json\n",
+		"ERROR     fetch failed\n  error: unavailable\n  details: status=503\n  operation: op1 job\n",
+		"  exception:\n    unavailable\n\n    goroutine 1 [running]:\n    main.main()\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output missing %q\ngot:\n%s", want, got)
+		}
+	}
+
+	if got := runCommand(t, input, "-stack=false"); strings.Contains(got, "goroutine") {
+		t.Errorf("stack trace shown when disabled:\n%s", got)
+	}
+}
+
+func TestFiltering(t *testing.T) {
+	got := runCommand(t, input, "-severity", "warning", "-json")
+	lines := strings.Split(strings.TrimSpace(got), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], "fetch failed") || !strings.Contains(lines[1], "retrying") {
+		t.Errorf("unexpected entries at or above warning:\n%s", got)
+	}
+
+	got = runCommand(t, input, "-filter", "labels.service = api", "-json")
+	if strings.Count(got, "\n") != 1 || !strings.Contains(got, "starting") {
+		t.Errorf("unexpected entries for filter:\n%s", got)
+	}
+}
+
+func TestGroup(t *testing.T) {
+	got := runCommand(t, input, "-group", "operation", "-color=false")
+	op := strings.Index(got, "== operation op1 (2 entries)")
+	none := strings.Index(got, "== no operation (2 entries)")
+	if op < 0 || none < op {
+		t.Fatalf("unexpected groups:\n%s", got)
+	}
+	if fetch, retry := strings.Index(got, "fetch failed"), strings.Index(got, "retrying"); fetch < op || retry > none {
+		t.Errorf("entries not grouped:\n%s", got)
+	}
+}
+
+func TestCloudLoggingInput(t *testing.T) {
+	in := `[
+  {
+    "timestamp": "2026-10-18T12:00:00.5Z",
+    "severity": "ERROR",
+    "labels": {"service": "api"},
+    "trace": "projects/p/traces/t1",
+    "spanId": "s1",
+    "sourceLocation": {"file": "main.go", "line": "10", "function": "main.main"},
+    "jsonPayload": {"message": "failed", "details": {"patient": "abc"}}
+  },
+  {"timestamp": "2026-10-18T12:00:01Z", "severity": "INFO", "textPayload": "plain"}
+]`
+	got := runCommand(t, in, "-utc", "-color=false", "-filter", "trace ~ t1")
+	want := "2026-10-18T12:00:00.500Z ERROR     failed\n" +
+		"  labels: service=api\n" +
+		"  details: patient=abc\n" +
+		"  trace: projects/p/traces/t1 span s1\n" +
+		"  source: main.go:10 main.main\n"
+	if got != want {
+		t.Errorf("unexpected output\nwant:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func TestLenientInput(t *testing.T) {
+	long := strings.Repeat("x", 2*1024*1024)
+	in := `{"message":"custom","severity":"TRACE"}` + "\n" +
+		`{"message":"` + long + `","severity":"INFO"}` + "\n" +
+		`{"message":"last","severity":"ERROR"}`
+	got := runCommand(t, in, "-color=false")
+	for _, want := range []string{"DEFAULT   custom\n", "INFO      " + long + "\n", "ERROR     last\n"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output missing %.40q\ngot:\n%.200s", want, got)
+		}
+	}
+	if strings.Contains(got, `"severity":"TRACE"`) {
+		t.Errorf("entry with unknown severity rendered as text:\n%s", got)
+	}
+}
+
+func TestInvalidArguments(t *testing.T) {
+	for _, args := range [][]string{
+		{"-filter", "unknown = 1"},
+		{"-group", "severity"},
+		{"-severity", "loud"},
+	} {
+		var out, errs bytes.Buffer
+		if err := run(args, strings.NewReader(""), &out, &errs); err == nil {
+			t.Errorf("expected error for %v", args)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/ParticleHealth/tau/slog"
+)
+
+// record read from a log stream. Lines that are not JSON are kept as text.
+type record struct {
+	slog.Entry
+	Time time.Time
+	raw  []byte
+	text bool
+}
+
+// cloudEntry as returned by gcloud logging read, with the payload written by tau in jsonPayload.
+type cloudEntry struct {
+	Timestamp      string               `json:"timestamp"`
+	Severity       severity             `json:"severity"`
+	Labels         map[string]string    `json:"labels"`
+	SourceLocation *slog.SourceLocation `json:"sourceLocation"`
+	Operation      *slog.Operation      `json:"operation"`
+	Trace          string               `json:"trace"`
+	SpanID         string               `json:"spanId"`
+	TraceSampled   bool                 `json:"traceSampled"`
+	HTTPRequest    *slog.HTTPRequest    `json:"httpRequest"`
+	JSONPayload    *tauEntry            `json:"jsonPayload"`
+	TextPayload    *string              `json:"textPayload"`
+	ProtoPayload   interface{}          `json:"protoPayload"`
+}
+
+// severity of an entry, falling back to DEFAULT for names tau does not know rather than failing to decode.
+type severity slog.Severity
+
+// UnmarshalJSON of a severity name or number.
+func (s *severity) UnmarshalJSON(b []byte) error {
+	var v slog.Severity
+	if err := v.UnmarshalJSON(b); err != nil {
+		v = slog.SeverityDefault
+	}
+	*s = severity(v)
+	return nil
+}
+
+// tauEntry as written by tau, with a lenient severity.
+type tauEntry struct {
+	slog.Entry
+	Severity severity `json:"severity"`
+}
+
+// entry with the decoded severity.
+func (e *tauEntry) entry() slog.Entry {
+	entry := e.Entry
+	entry.Severity = slog.Severity(e.Severity)
+	return entry
+}
+
+// timestamps that may be added to entries written to stdout by the runtime or a log shipper.
+type timestamps struct {
+	Time string `json:"time"`
+}
+
+// parseTime in RFC 3339 format, leaving it unset if it is not.
+func parseTime(s string) time.Time {
+	t, _ := time.Parse(time.RFC3339Nano, s)
+	return t
+}
+
+// decode a JSON entry, either as written by tau or as read back from Cloud Logging.
+func decode(b []byte) (*record, error) {
+	var c cloudEntry
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, err
+	}
+	r := &record{raw: b, Time: parseTime(c.Timestamp)}
+	if c.JSONPayload == nil && c.TextPayload == nil && c.ProtoPayload == nil {
+		var e tauEntry
+		if err := json.Unmarshal(b, &e); err != nil {
+			return nil, err
+		}
+		r.Entry = e.entry()
+		if r.Time.IsZero() {
+			var ts timestamps
+			if json.Unmarshal(b, &ts) == nil {
+				r.Time = parseTime(ts.Time)
+			}
+		}
+		return r, nil
+	}
+
+	if c.JSONPayload != nil {
+		r.Entry = c.JSONPayload.entry()
+	} else if c.TextPayload != nil {
+		r.Message = *c.TextPayload
+	}
+	r.Severity = slog.Severity(c.Severity)
+	r.Labels = c.Labels
+	r.SourceLocation = c.SourceLocation
+	r.Operation = c.Operation
+	r.Trace = c.Trace
+	r.SpanID = c.SpanID
+	r.TraceSampled = c.TraceSampled
+	r.HTTPRequest = c.HTTPRequest
+	r.ProtoPayload = c.ProtoPayload
+	return r, nil
+}
+
+// read records from r, calling fn for each. Newline delimited JSON and JSON arrays, such as the output of
+// gcloud logging read --format=json, are supported.
+func read(r io.Reader, fn func(*record)) error {
+	br := bufio.NewReaderSize(r, 64*1024)
+	for {
+		c, err := br.ReadByte()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if c == ' ' || c == '\t' || c == '\r' || c == '\n' {
+			continue
+		}
+		_ = br.UnreadByte()
+		if c == '[' {
+			return readArray(br, fn)
+		}
+		return readLines(br, fn)
+	}
+}
+
+// readArray of JSON entries.
+func readArray(r io.Reader, fn func(*record)) error {
+	dec := json.NewDecoder(r)
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+	for dec.More() {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return err
+		}
+		rec, err := decode(raw)
+		if err != nil {
+			rec = &record{raw: raw, text: true}
+		}
+		fn(rec)
+	}
+	_, err := dec.Token()
+	return err
+}
+
+// readLines of JSON, with any other lines kept as text. Lines of any length are read whole.
+func readLines(r *bufio.Reader, fn func(*record)) error {
+	for n := 1; ; n++ {
+		b, err := r.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return fmt.Errorf("reading line %d: %w", n, err)
+		}
+		if line := bytes.TrimSpace(b); len(line) > 0 {
+			rec, decErr := decode(line)
+			if decErr != nil || line[0] != '{' {
+				rec = &record{raw: line, text: true}
+			}
+			fn(rec)
+		}
+		if err == io.EOF {
+			return nil
+		}
+	}
+}
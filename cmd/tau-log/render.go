@@ -0,0 +1,262 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ParticleHealth/tau/slog"
+)
+
+// timeFormat of entry timestamps.
+const timeFormat = "2006-01-02T15:04:05.000Z07:00"
+
+// ANSI escape codes used when color is enabled.
+const (
+	ansiReset  = "\x1b[0m"
+	ansiBold   = "\x1b[1m"
+	ansiDim    = "\x1b[2m"
+	ansiRed    = "\x1b[31m"
+	ansiYellow = "\x1b[33m"
+	ansiBlue   = "\x1b[34m"
+	ansiCyan   = "\x1b[36m"
+)
+
+// renderer writes records in a readable multi-line format.
+type renderer struct {
+	w     io.Writer
+	color bool
+	stack bool
+	utc   bool
+}
+
+// paint s with the escape code if color is enabled.
+func (p *renderer) paint(code, s string) string {
+	if !p.color || s == "" {
+		return s
+	}
+	return code + s + ansiReset
+}
+
+// severityColor used for the severity name.
+func severityColor(s slog.Severity) string {
+	switch {
+	case s >= slog.SeverityError:
+		return ansiRed
+	case s >= slog.SeverityWarning:
+		return ansiYellow
+	case s >= slog.SeverityNotice:
+		return ansiCyan
+	case s >= slog.SeverityInfo:
+		return ansiBlue
+	default:
+		return ansiDim
+	}
+}
+
+// header for a group of records.
+func (p *renderer) header(title string, n int) {
+	fmt.Fprintf(p.w, "%s\n", p.paint(ansiBold, fmt.Sprintf("== %s (%d %s)", title, n, plural(n, "entry", "entries"))))
+}
+
+// plural form of a word for n.
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
+
+// render a record. Text records are written unchanged.
+func (p *renderer) render(r *record) {
+	if r.text {
+		fmt.Fprintf(p.w, "%s\n", r.raw)
+		return
+	}
+	e := &r.Entry
+	var b strings.Builder
+	if !r.Time.IsZero() {
+		t := r.Time
+		if p.utc {
+			t = t.UTC()
+		} else {
+			t = t.Local()
+		}
+		b.WriteString(p.paint(ansiDim, t.Format(timeFormat)))
+		b.WriteByte(' ')
+	}
+	b.WriteString(p.paint(severityColor(e.Severity), fmt.Sprintf("%-9s", e.Severity)))
+	b.WriteByte(' ')
+	b.WriteString(e.Message)
+	b.WriteByte('\n')
+
+	if e.Err != "" {
+		p.line(&b, "error", p.paint(ansiRed, e.Err))
+	}
+	if len(e.Labels) > 0 {
+		p.line(&b, "labels", pairs(e.Labels))
+	}
+	if len(e.Details) > 0 {
+		details := make(map[string]string, len(e.Details))
+		for k, v := range e.Details {
+			details[k] = valueString(v)
+		}
+		p.line(&b, "details", pairs(details))
+	}
+	if h := e.HTTPRequest; h != nil {
+		req := strings.TrimSpace(h.RequestMethod + " " + h.RequestURL)
+		if h.Status != 0 {
+			req += fmt.Sprintf(" %d", h.Status)
+		}
+		p.line(&b, "http", req)
+	}
+	if op := e.Operation; op != nil {
+		v := op.ID
+		if op.Producer != "" {
+			v += " " + op.Producer
+		}
+		if op.First {
+			v += " [first]"
+		}
+		if op.Last {
+			v += " [last]"
+		}
+		p.line(&b, "operation", v)
+	}
+	if e.Trace != "" {
+		v := e.Trace
+		if e.SpanID != "" {
+			v += " span " + e.SpanID
+		}
+		if e.TraceSampled {
+			v += " [sampled]"
+		}
+		p.line(&b, "trace", v)
+	}
+	if s := e.SourceLocation; s != nil && s.File != "" {
+		v := s.File
+		if s.Line != "" {
+			v += ":" + s.Line
+		}
+		if s.Function != "" {
+			v += " " + s.Function
+		}
+		p.line(&b, "source", p.paint(ansiDim, v))
+	}
+	if p.stack && e.StackTrace != "" {
+		b.WriteString("  ")
+		b.WriteString(p.paint(ansiBold, "exception:"))
+		b.WriteByte('\n')
+		for _, l := range strings.Split(strings.TrimRight(e.StackTrace, "\n"), "\n") {
+			if l != "" {
+				b.WriteString("    ")
+				b.WriteString(l)
+			}
+			b.WriteByte('\n')
+		}
+	}
+	_, _ = io.WriteString(p.w, b.String())
+}
+
+// line of an indented key and value.
+func (p *renderer) line(b *strings.Builder, key, value string) {
+	b.WriteString("  ")
+	b.WriteString(p.paint(ansiBold, key+":"))
+	b.WriteByte(' ')
+	b.WriteString(value)
+	b.WriteByte('\n')
+}
+
+// pairs of key=value sorted by key, with values quoted when they contain spaces.
+func pairs(m map[string]string) string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	for i, k := range keys {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		v := m[k]
+		if v == "" || strings.ContainsAny(v, " \t\n") {
+			v = fmt.Sprintf("%q", v)
+		}
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(v)
+	}
+	return b.String()
+}
+
+// group of records sharing an operation or trace.
+type group struct {
+	key     string
+	records []*record
+}
+
+// grouper collects records by key, keeping groups in the order they were first seen.
+type grouper struct {
+	by     string
+	groups []*group
+	index  map[string]*group
+}
+
+// add a record to its group.
+func (g *grouper) add(r *record) {
+	var key string
+	if !r.text {
+		key, _ = r.field(g.by)
+	}
+	grp, ok := g.index[key]
+	if !ok {
+		grp = &group{key: key}
+		g.index[key] = grp
+		g.groups = append(g.groups, grp)
+	}
+	grp.records = append(grp.records, r)
+}
+
+// render all groups, with records that have no key last.
+func (g *grouper) render(p *renderer) {
+	var none *group
+	for _, grp := range g.groups {
+		if grp.key == "" {
+			none = grp
+			continue
+		}
+		p.header(g.by+" "+grp.key+span(grp.records), len(grp.records))
+		for _, r := range grp.records {
+			p.render(r)
+		}
+	}
+	if none != nil {
+		p.header("no "+g.by, len(none.records))
+		for _, r := range none.records {
+			p.render(r)
+		}
+	}
+}
+
+// span of time covered by records, if they have timestamps.
+func span(records []*record) string {
+	var first, last time.Time
+	for _, r := range records {
+		if r.Time.IsZero() {
+			continue
+		}
+		if first.IsZero() || r.Time.Before(first) {
+			first = r.Time
+		}
+		if r.Time.After(last) {
+			last = r.Time
+		}
+	}
+	if first.IsZero() {
+		return ""
+	}
+	return " over " + last.Sub(first).String()
+}
@@ -5,6 +5,7 @@ go 1.17
 require (
 	github.com/google/go-cmp v0.6.0
 	go.opencensus.io v0.24.0
+	google.golang.org/protobuf v1.33.0
 )
 
 require github.com/golang/groupcache v0.0.0-20210331224755-41bb18bfe9da // indirect
@@ -0,0 +1,230 @@
+package slog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// AuditLogType of the protoPayload written for audit events.
+const AuditLogType = "type.googleapis.com/google.cloud.audit.AuditLog"
+
+// LabelLogName set on audit entries so they can be routed separately from other logs.
+const LabelLogName = "log_name"
+
+// DefaultAuditLogName used for LabelLogName unless changed with SetLogName.
+const DefaultAuditLogName = "audit"
+
+// Outcomes of an audited action.
+const (
+	OutcomeSuccess = "success"
+	OutcomeDenied  = "denied"
+	OutcomeFailure = "failure"
+)
+
+// Status codes for outcomes, as specified in https://cloud.google.com/apis/design/errors#handling_errors.
+var outcomeCodes = map[string]int{
+	OutcomeSuccess: 0,
+	OutcomeDenied:  7,
+	OutcomeFailure: 2,
+}
+
+// AuditEvent records an actor's access to a protected resource, such as a patient record.
+type AuditEvent struct {
+	// Actor performing the action, such as a user email or service account. Required.
+	Actor string
+	// Action performed, such as "fhir.Patient.read". Required.
+	Action string
+	// ResourceType and ResourceID accessed, such as "Patient" and "123". Required.
+	ResourceType string
+	ResourceID   string
+	// PurposeOfUse for the access, such as "TREAT". Required.
+	PurposeOfUse string
+	// Outcome of the action, one of OutcomeSuccess, OutcomeDenied or OutcomeFailure. Required.
+	Outcome string
+	// Reason for the outcome, included as the status message.
+	Reason string
+	// SourceIP of the caller.
+	SourceIP string
+}
+
+// Validate that all required fields are set.
+func (ev *AuditEvent) Validate() error {
+	var missing []string
+	for _, f := range []struct{ name, value string }{
+		{"actor", ev.Actor},
+		{"action", ev.Action},
+		{"resource type", ev.ResourceType},
+		{"resource ID", ev.ResourceID},
+		{"purpose of use", ev.PurposeOfUse},
+		{"outcome", ev.Outcome},
+	} {
+		if f.value == "" {
+			missing = append(missing, f.name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("audit event missing %s", strings.Join(missing, ", "))
+	}
+	if _, ok := outcomeCodes[ev.Outcome]; !ok {
+		return fmt.Errorf("audit event has unknown outcome %q", ev.Outcome)
+	}
+	return nil
+}
+
+// AuditLog payload compatible with Cloud Audit Logs.
+// See https://cloud.google.com/logging/docs/reference/audit/auditlog/rest/Shared.Types/AuditLog for reference.
+type AuditLog struct {
+	Type               string                  `json:"@type"`
+	MethodName         string                  `json:"methodName"`
+	ResourceName       string                  `json:"resourceName"`
+	AuthenticationInfo AuditAuthenticationInfo `json:"authenticationInfo"`
+	RequestMetadata    *AuditRequestMetadata   `json:"requestMetadata,omitempty"`
+	Status             AuditStatus             `json:"status"`
+	Metadata           AuditMetadata           `json:"metadata"`
+}
+
+// AuditAuthenticationInfo identifying the actor.
+type AuditAuthenticationInfo struct {
+	PrincipalEmail   string `json:"principalEmail,omitempty"`
+	PrincipalSubject string `json:"principalSubject,omitempty"`
+}
+
+// AuditRequestMetadata about the caller.
+type AuditRequestMetadata struct {
+	CallerIP string `json:"callerIp,omitempty"`
+}
+
+// AuditStatus of the action.
+type AuditStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+// AuditMetadata not covered by the standard AuditLog fields, including the integrity chain if enabled.
+type AuditMetadata struct {
+	ResourceType string     `json:"resourceType"`
+	ResourceID   string     `json:"resourceId"`
+	PurposeOfUse string     `json:"purposeOfUse"`
+	Outcome      string     `json:"outcome"`
+	Integrity    *Integrity `json:"integrity,omitempty"`
+}
+
+// newAuditLog payload for a validated event.
+func newAuditLog(ev *AuditEvent) *AuditLog {
+	a := &AuditLog{
+		Type:         AuditLogType,
+		MethodName:   ev.Action,
+		ResourceName: fmt.Sprint(ev.ResourceType, "/", ev.ResourceID),
+		Status:       AuditStatus{Code: outcomeCodes[ev.Outcome], Message: ev.Reason},
+		Metadata: AuditMetadata{
+			ResourceType: ev.ResourceType,
+			ResourceID:   ev.ResourceID,
+			PurposeOfUse: ev.PurposeOfUse,
+			Outcome:      ev.Outcome,
+		},
+	}
+	if strings.Contains(ev.Actor, "@") {
+		a.AuthenticationInfo.PrincipalEmail = ev.Actor
+	} else {
+		a.AuthenticationInfo.PrincipalSubject = ev.Actor
+	}
+	if ev.SourceIP != "" {
+		a.RequestMetadata = &AuditRequestMetadata{CallerIP: ev.SourceIP}
+	}
+	return a
+}
+
+// chainPayload of an AuditLog, which is its JSON without integrity values.
+func (a *AuditLog) chainPayload() ([]byte, error) {
+	c := *a
+	c.Metadata.Integrity = nil
+	return json.Marshal(&c)
+}
+
+// VerifyAuditChain of audit logs, in the order they were written, by an AuditLogger in integrity mode
+// using the same KeyProvider. Logs without integrity values are reported as invalid by their position
+// starting at 1. Each restart of the process starts a new chain at sequence 1, which is not reported.
+func VerifyAuditChain(logs []*AuditLog, kp KeyProvider) (*IntegrityReport, error) {
+	v := &integrityVerifier{kp: kp}
+	for i, a := range logs {
+		if a.Metadata.Integrity == nil {
+			v.invalid(i + 1)
+			continue
+		}
+		payload, err := a.chainPayload()
+		if err != nil {
+			return nil, fmt.Errorf("encoding audit log %d: %w", i+1, err)
+		}
+		if err := v.add(payload, a.Metadata.Integrity); err != nil {
+			return nil, fmt.Errorf("verifying audit log %d: %w", i+1, err)
+		}
+	}
+	return v.finish(), nil
+}
+
+// AuditLogger writes audit events to a dedicated Logger, separate from debug logs.
+type AuditLogger struct {
+	mu    sync.Mutex // ensures chained entries are written in order
+	entry *Entry
+	chain *integrityChain
+}
+
+// NewAuditLogger writing audit events to l.
+func NewAuditLogger(l *Logger) *AuditLogger {
+	return &AuditLogger{entry: l.WithLabels(Fields{LabelLogName: DefaultAuditLogName})}
+}
+
+// SetLogName included as a label on all audit entries.
+func (a *AuditLogger) SetLogName(name string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.entry = a.entry.WithLabels(Fields{LabelLogName: name})
+}
+
+// SetIntegrity mode chaining each audit event to the previous one so gaps or edits can be detected,
+// using the same chain as Sink.SetIntegrity with an HMAC if kp is not nil. See VerifyAuditChain.
+// The integrity values are written in the payload metadata so they are kept by any sink.
+//
+// The chain is held in memory, so it starts again at sequence 1 when the process restarts and
+// setting it again also starts a new chain.
+func (a *AuditLogger) SetIntegrity(kp KeyProvider) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.chain = &integrityChain{kp: kp}
+}
+
+// Log an audit event, including the trace from any Entry in ctx.
+// Successful access is logged at Info, denied or failed access at Warning.
+func (a *AuditLogger) Log(ctx context.Context, ev AuditEvent) error {
+	if err := ev.Validate(); err != nil {
+		return err
+	}
+	payload := newAuditLog(&ev)
+	s := SeverityInfo
+	if ev.Outcome != OutcomeSuccess {
+		s = SeverityWarning
+	}
+	msg := fmt.Sprint(ev.Actor, " ", ev.Action, " ", payload.ResourceName, ": ", ev.Outcome)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.chain != nil {
+		b, err := payload.chainPayload()
+		if err != nil {
+			return fmt.Errorf("encoding audit event: %w", err)
+		}
+		if payload.Metadata.Integrity, err = a.chain.next(b); err != nil {
+			return err
+		}
+	}
+	e := a.entry.clone()
+	e.ProtoPayload = payload
+	if c, ok := ctx.Value(entryKey).(*Entry); ok {
+		e.Trace, e.SpanID, e.TraceSampled = c.Trace, c.SpanID, c.TraceSampled
+	}
+	e.logger.log(e, s, msg, 2)
+	return nil
+}
@@ -0,0 +1,185 @@
+package slog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// auditRecord decoded from an audit entry written to stdout.
+type auditRecord struct {
+	Severity     Severity          `json:"severity"`
+	Labels       map[string]string `json:"logging.googleapis.com/labels"`
+	Trace        string            `json:"logging.googleapis.com/trace"`
+	ProtoPayload *AuditLog         `json:"protoPayload"`
+}
+
+func decodeAuditLogs(t *testing.T, b []byte) []auditRecord {
+	t.Helper()
+	var records []auditRecord
+	dec := json.NewDecoder(bytes.NewReader(b))
+	for dec.More() {
+		var r auditRecord
+		if err := dec.Decode(&r); err != nil {
+			t.Fatalf("decoding audit entry: %v", err)
+		}
+		records = append(records, r)
+	}
+	return records
+}
+
+func testAuditEvent() AuditEvent {
+	return AuditEvent{
+		Actor:        "clinician@example.com",
+		Action:       "fhir.Patient.read",
+		ResourceType: "Patient",
+		ResourceID:   "123",
+		PurposeOfUse: "TREAT",
+		Outcome:      OutcomeSuccess,
+		SourceIP:     "10.0.0.1",
+	}
+}
+
+func TestAuditEventValidate(t *testing.T) {
+	ev := testAuditEvent()
+	if err := ev.Validate(); err != nil {
+		t.Errorf("unexpected error for valid event: %v", err)
+	}
+	ev.Actor = ""
+	ev.PurposeOfUse = ""
+	err := ev.Validate()
+	if err == nil || !strings.Contains(err.Error(), "actor, purpose of use") {
+		t.Errorf("missing fields not reported: %v", err)
+	}
+	ev = testAuditEvent()
+	ev.Outcome = "maybe"
+	if err := ev.Validate(); err == nil {
+		t.Error("unknown outcome not reported")
+	}
+}
+
+func TestAuditLogger(t *testing.T) {
+	out := bytes.NewBuffer(make([]byte, 0, 4096))
+	logger := newLogger(out)
+	logger.SetProject("test-project")
+	audit := NewAuditLogger(logger)
+	audit.SetLogName("phi-access")
+
+	ctx := NewContext(context.Background(), logger.entry())
+	ctx.Value(entryKey).(*Entry).Trace = "projects/test-project/traces/abc"
+	if err := audit.Log(ctx, testAuditEvent()); err != nil {
+		t.Fatalf("logging audit event: %v", err)
+	}
+	denied := testAuditEvent()
+	denied.Actor = "service-account"
+	denied.Outcome = OutcomeDenied
+	denied.Reason = "not authorized"
+	if err := audit.Log(context.Background(), denied); err != nil {
+		t.Fatalf("logging audit event: %v", err)
+	}
+	if err := audit.Log(context.Background(), AuditEvent{}); err == nil {
+		t.Error("invalid event logged")
+	}
+
+	records := decodeAuditLogs(t, out.Bytes())
+	if len(records) != 2 {
+		t.Fatalf("unexpected number of audit entries\nwant: 2\ngot: %d", len(records))
+	}
+	first := records[0]
+	if first.Labels[LabelLogName] != "phi-access" {
+		t.Errorf("log name label not set: %v", first.Labels)
+	}
+	if first.Trace != "projects/test-project/traces/abc" {
+		t.Errorf("trace not taken from context: %q", first.Trace)
+	}
+	p := first.ProtoPayload
+	if p == nil {
+		t.Fatalf("protoPayload missing: %s", out)
+	}
+	if p.Type != AuditLogType || p.MethodName != "fhir.Patient.read" || p.ResourceName != "Patient/123" {
+		t.Errorf("unexpected payload: %+v", p)
+	}
+	if p.AuthenticationInfo.PrincipalEmail != "clinician@example.com" {
+		t.Errorf("actor not set: %+v", p.AuthenticationInfo)
+	}
+	if p.RequestMetadata == nil || p.RequestMetadata.CallerIP != "10.0.0.1" {
+		t.Errorf("source IP not set: %+v", p.RequestMetadata)
+	}
+	if p.Metadata.PurposeOfUse != "TREAT" || p.Metadata.Integrity != nil {
+		t.Errorf("unexpected metadata: %+v", p.Metadata)
+	}
+	second := records[1]
+	if second.Severity != SeverityWarning {
+		t.Errorf("denied access not logged at warning: %s", second.Severity)
+	}
+	if second.ProtoPayload.Status.Code != 7 || second.ProtoPayload.Status.Message != "not authorized" {
+		t.Errorf("unexpected status: %+v", second.ProtoPayload.Status)
+	}
+	if second.ProtoPayload.AuthenticationInfo.PrincipalSubject != "service-account" {
+		t.Errorf("actor not set: %+v", second.ProtoPayload.AuthenticationInfo)
+	}
+}
+
+func TestAuditHashChain(t *testing.T) {
+	out := bytes.NewBuffer(make([]byte, 0, 4096))
+	audit := NewAuditLogger(newLogger(out))
+	key := StaticKey([]byte("secret"))
+	audit.SetIntegrity(key)
+	for i := 0; i < 4; i++ {
+		if err := audit.Log(context.Background(), testAuditEvent()); err != nil {
+			t.Fatalf("logging audit event: %v", err)
+		}
+	}
+	var logs []*AuditLog
+	for _, r := range decodeAuditLogs(t, out.Bytes()) {
+		logs = append(logs, r.ProtoPayload)
+	}
+	if report, err := VerifyAuditChain(logs, key); err != nil || !report.OK() {
+		t.Fatalf("valid chain failed verification: %+v, %v", report, err)
+	}
+	if in := logs[0].Metadata.Integrity; in == nil || in.Seq != 1 || in.Prev != "" {
+		t.Errorf("unexpected start of chain: %+v", in)
+	}
+	if report, err := VerifyAuditChain(logs, StaticKey([]byte("other"))); err != nil || len(report.Modified) != 4 {
+		t.Errorf("chain verified with the wrong key: %+v, %v", report, err)
+	}
+
+	// A restarted process starts a new chain.
+	restarted := NewAuditLogger(newLogger(out))
+	restarted.SetIntegrity(key)
+	out.Reset()
+	if err := restarted.Log(context.Background(), testAuditEvent()); err != nil {
+		t.Fatalf("logging audit event: %v", err)
+	}
+	all := append([]*AuditLog{}, logs...)
+	all = append(all, decodeAuditLogs(t, out.Bytes())[0].ProtoPayload)
+	if report, err := VerifyAuditChain(all, key); err != nil || !report.OK() {
+		t.Errorf("restarted chain failed verification: %+v, %v", report, err)
+	}
+
+	tests := []struct {
+		name string
+		logs func() []*AuditLog
+	}{
+		{"gap", func() []*AuditLog { return []*AuditLog{logs[0], logs[2], logs[3]} }},
+		{"reordered", func() []*AuditLog { return []*AuditLog{logs[0], logs[2], logs[1], logs[3]} }},
+		{"modified", func() []*AuditLog {
+			c := *logs[1]
+			c.Metadata.PurposeOfUse = "PAYMENT"
+			return []*AuditLog{logs[0], &c, logs[2], logs[3]}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := VerifyAuditChain(tt.logs(), key)
+			if err != nil {
+				t.Fatalf("verifying: %v", err)
+			}
+			if report.OK() {
+				t.Error("tampering not detected")
+			}
+		})
+	}
+}
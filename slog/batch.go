@@ -0,0 +1,165 @@
+package slog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// batchConfig of when a batchWriter sends and how it retries, with defaults applied.
+type batchConfig struct {
+	count      int
+	bytes      int
+	delay      time.Duration
+	maxRetries int
+	backoff    time.Duration
+}
+
+// newBatchConfig with defaults for unset values. Retries are disabled if maxRetries is negative.
+func newBatchConfig(count, bytes int, delay time.Duration, maxRetries int, backoff time.Duration) batchConfig {
+	cfg := batchConfig{count: count, bytes: bytes, delay: delay, maxRetries: maxRetries, backoff: backoff}
+	if cfg.count <= 0 {
+		cfg.count = defaultBatchCount
+	}
+	if cfg.bytes <= 0 {
+		cfg.bytes = defaultBatchBytes
+	}
+	if cfg.delay <= 0 {
+		cfg.delay = defaultBatchDelay
+	}
+	if cfg.maxRetries < 0 {
+		cfg.maxRetries = 0
+	} else if cfg.maxRetries == 0 {
+		cfg.maxRetries = defaultMaxRetries
+	}
+	if cfg.backoff <= 0 {
+		cfg.backoff = defaultBackoff
+	}
+	return cfg
+}
+
+// batchWriter batches encoded entries, one per Write, and sends them with post.
+type batchWriter struct {
+	dropped uint64 // first to keep 64-bit alignment for atomic access
+	cfg     batchConfig
+	// post a batch once, reporting whether a failure is worth retrying.
+	post func(batch []json.RawMessage) (bool, error)
+
+	// mu guards the pending batch and is held while sending, so batches are sent in the order they were taken.
+	mu      sync.Mutex
+	entries []json.RawMessage
+	size    int
+	timer   *time.Timer
+}
+
+// Write a single encoded entry to the pending batch, sending it once full.
+// Errors sending a batch are returned once its entries are dropped, an entry not yet sent is kept for the next batch.
+func (w *batchWriter) Write(p []byte) (int, error) {
+	entry := make(json.RawMessage, len(p))
+	copy(entry, p)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var err error
+	if w.size > 0 && w.size+len(entry) > w.cfg.bytes {
+		err = w.send(w.take())
+	}
+	w.entries = append(w.entries, entry)
+	w.size += len(entry)
+	if len(w.entries) < w.cfg.count && w.size < w.cfg.bytes {
+		if w.timer == nil {
+			w.timer = time.AfterFunc(w.cfg.delay, w.flushOnTimer)
+		}
+		return len(p), err
+	}
+	if sendErr := w.send(w.take()); sendErr != nil {
+		err = sendErr
+	}
+	return len(p), err
+}
+
+// take the pending batch. Must be called with mu held.
+func (w *batchWriter) take() []json.RawMessage {
+	batch := w.entries
+	w.entries = nil
+	w.size = 0
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
+	return batch
+}
+
+// flushOnTimer once the batch delay has passed.
+func (w *batchWriter) flushOnTimer() {
+	if err := w.Flush(); err != nil {
+		fmt.Fprintln(os.Stderr, "could not write log:", err)
+	}
+}
+
+// Flush the pending batch.
+func (w *batchWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.send(w.take())
+}
+
+// Dropped returns the number of entries in batches that could not be sent.
+func (w *batchWriter) Dropped() uint64 {
+	return atomic.LoadUint64(&w.dropped)
+}
+
+// send a batch, retrying transient failures with exponential backoff.
+// Entries of a batch that still fails are dropped and counted. Must be called with mu held.
+func (w *batchWriter) send(batch []json.RawMessage) error {
+	if len(batch) == 0 {
+		return nil
+	}
+	backoff := w.cfg.backoff
+	for attempt := 0; ; attempt++ {
+		retry, err := w.post(batch)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt >= w.cfg.maxRetries {
+			atomic.AddUint64(&w.dropped, uint64(len(batch)))
+			return fmt.Errorf("dropped %d entries after %d attempts: %w", len(batch), attempt+1, err)
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// postBody to an endpoint once, reporting whether a failure is worth retrying.
+func postBody(client *http.Client, endpoint string, header http.Header, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if resp.StatusCode == http.StatusOK {
+		return false, nil
+	}
+	err = fmt.Errorf("unexpected status %s: %s", resp.Status, bytes.TrimSpace(msg))
+	switch resp.StatusCode {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests, http.StatusInternalServerError,
+		http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true, err
+	}
+	return false, err
+}
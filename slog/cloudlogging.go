@@ -0,0 +1,184 @@
+package slog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// CloudLoggingEndpoint for writing entries to the Cloud Logging API.
+// See https://cloud.google.com/logging/docs/reference/v2/rest/v2/entries/write for reference.
+const CloudLoggingEndpoint = "https://logging.googleapis.com/v2/entries:write"
+
+// Defaults used for unset CloudLoggingConfig values.
+const (
+	defaultBatchCount   = 500
+	defaultBatchBytes   = 1024 * 1024
+	defaultBatchDelay   = time.Second
+	defaultMaxRetries   = 3
+	defaultBackoff      = 100 * time.Millisecond
+	defaultQueueSize    = 1000
+	defaultLogName      = "tau"
+	defaultResourceType = "global"
+)
+
+// MonitoredResource an entry is associated with in Cloud Logging.
+// See https://cloud.google.com/logging/docs/api/v2/resource-list for reference.
+type MonitoredResource struct {
+	Type   string            `json:"type"`
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// CloudLoggingConfig for a Sink writing directly to the Cloud Logging API.
+type CloudLoggingConfig struct {
+	// Endpoint to write entries to, defaults to CloudLoggingEndpoint.
+	Endpoint string
+	// Project entries are written to. Required unless LogName is a full resource name.
+	Project string
+	// LogName either as a short name or a full projects/<project>/logs/<name> resource name.
+	LogName string
+	// Resource entries are associated with, defaults to global.
+	Resource *MonitoredResource
+	// Client used for requests. Must add credentials, such as one from golang.org/x/oauth2/google.
+	Client *http.Client
+	// BatchCount, BatchBytes and BatchDelay at which a batch of entries is sent, whichever is reached first.
+	BatchCount int
+	BatchBytes int
+	BatchDelay time.Duration
+	// MaxRetries for transient failures, defaults to 3 and disabled if negative.
+	// The delay between attempts starts at Backoff and doubles each attempt.
+	MaxRetries int
+	Backoff    time.Duration
+	// QueueSize of entries waiting to be batched before further entries are dropped.
+	QueueSize int
+}
+
+// logEntry as expected by the Cloud Logging API.
+// See https://cloud.google.com/logging/docs/reference/v2/rest/v2/LogEntry for reference.
+type logEntry struct {
+	Timestamp      string            `json:"timestamp"`
+	Severity       Severity          `json:"severity,omitempty"`
+	Labels         map[string]string `json:"labels,omitempty"`
+	SourceLocation *SourceLocation   `json:"sourceLocation,omitempty"`
+	Operation      *Operation        `json:"operation,omitempty"`
+	Trace          string            `json:"trace,omitempty"`
+	SpanID         string            `json:"spanId,omitempty"`
+	TraceSampled   bool              `json:"traceSampled,omitempty"`
+	HTTPRequest    *HTTPRequest      `json:"httpRequest,omitempty"`
+	JSONPayload    *logEntryPayload  `json:"jsonPayload,omitempty"`
+	ProtoPayload   interface{}       `json:"protoPayload,omitempty"`
+}
+
+// logEntryPayload keeps the same shape as entries written to stdout.
+type logEntryPayload struct {
+	Message    string `json:"message"`
+	Err        string `json:"error,omitempty"`
+	Details    Fields `json:"details,omitempty"`
+	StackTrace string `json:"exception,omitempty"`
+}
+
+// writeRequest body for entries.write.
+type writeRequest struct {
+	LogName        string             `json:"logName"`
+	Resource       *MonitoredResource `json:"resource"`
+	Entries        []json.RawMessage  `json:"entries"`
+	PartialSuccess bool               `json:"partialSuccess"`
+}
+
+// CloudLoggingEncoder maps an Entry onto a Cloud Logging API LogEntry.
+// Entries with a ProtoPayload, such as audit events, are written with it in place of jsonPayload.
+var CloudLoggingEncoder Encoder = EncoderFunc(func(buf *bytes.Buffer, e *Entry) error {
+	le := logEntry{
+		Timestamp:      time.Now().UTC().Format(time.RFC3339Nano),
+		Severity:       e.Severity,
+		Labels:         e.Labels,
+		SourceLocation: e.SourceLocation,
+		Operation:      e.Operation,
+		Trace:          e.Trace,
+		SpanID:         e.SpanID,
+		TraceSampled:   e.TraceSampled,
+		HTTPRequest:    e.HTTPRequest,
+		ProtoPayload:   e.ProtoPayload,
+	}
+	if e.ProtoPayload == nil {
+		le.JSONPayload = &logEntryPayload{
+			Message:    e.Message,
+			Err:        e.Err,
+			Details:    e.normalizedDetails(),
+			StackTrace: e.StackTrace,
+		}
+	}
+	return json.NewEncoder(buf).Encode(le)
+})
+
+// NewCloudLoggingSink writing entries directly to the Cloud Logging API.
+// Entries are queued and sent in batches so logging is never blocked on requests.
+// Close the sink before exiting to send any remaining entries.
+func NewCloudLoggingSink(cfg CloudLoggingConfig) (*Sink, error) {
+	w, err := newCloudLoggingWriter(cfg)
+	if err != nil {
+		return nil, err
+	}
+	size := cfg.QueueSize
+	if size <= 0 {
+		size = defaultQueueSize
+	}
+	s := NewAsyncSink(w, size)
+	s.SetEncoder(CloudLoggingEncoder)
+	return s, nil
+}
+
+// cloudLoggingWriter batches encoded LogEntries, one per Write, and sends them to the API.
+type cloudLoggingWriter struct {
+	*batchWriter
+	cfg     CloudLoggingConfig
+	logName string
+}
+
+// newCloudLoggingWriter with defaults applied to cfg.
+func newCloudLoggingWriter(cfg CloudLoggingConfig) (*cloudLoggingWriter, error) {
+	if cfg.LogName == "" {
+		cfg.LogName = defaultLogName
+	}
+	logName := cfg.LogName
+	if !strings.HasPrefix(logName, "projects/") {
+		if cfg.Project == "" {
+			return nil, errors.New("cloud logging sink requires a project or full log name")
+		}
+		logName = fmt.Sprint("projects/", cfg.Project, "/logs/", url.PathEscape(cfg.LogName))
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = CloudLoggingEndpoint
+	}
+	if cfg.Resource == nil {
+		cfg.Resource = &MonitoredResource{Type: defaultResourceType}
+	}
+	if cfg.Client == nil {
+		cfg.Client = http.DefaultClient
+	}
+	w := &cloudLoggingWriter{cfg: cfg, logName: logName}
+	w.batchWriter = &batchWriter{
+		cfg:  newBatchConfig(cfg.BatchCount, cfg.BatchBytes, cfg.BatchDelay, cfg.MaxRetries, cfg.Backoff),
+		post: w.post,
+	}
+	return w, nil
+}
+
+// post a batch to the API once, reporting whether a failure is worth retrying.
+func (w *cloudLoggingWriter) post(batch []json.RawMessage) (bool, error) {
+	body, err := json.Marshal(writeRequest{
+		LogName:        w.logName,
+		Resource:       w.cfg.Resource,
+		Entries:        batch,
+		PartialSuccess: true,
+	})
+	if err != nil {
+		return false, fmt.Errorf("marshaling entries: %w", err)
+	}
+	return postBody(w.cfg.Client, w.cfg.Endpoint, http.Header{"Content-Type": {"application/json"}}, body)
+}
@@ -0,0 +1,191 @@
+package slog
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeLoggingServer records entries.write requests, failing the first failures requests.
+type fakeLoggingServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests []writeRequest
+	attempts int
+	failures int
+}
+
+func newFakeLoggingServer(t *testing.T, failures int) *fakeLoggingServer {
+	t.Helper()
+	f := &fakeLoggingServer{failures: failures}
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.attempts++
+		if f.attempts <= f.failures {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var req writeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.requests = append(f.requests, req)
+		_, _ = w.Write([]byte("{}"))
+	}))
+	t.Cleanup(f.Close)
+	return f
+}
+
+func (f *fakeLoggingServer) entries(t *testing.T) []logEntry {
+	t.Helper()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var entries []logEntry
+	for _, req := range f.requests {
+		for _, raw := range req.Entries {
+			var e logEntry
+			if err := json.Unmarshal(raw, &e); err != nil {
+				t.Fatalf("decoding entry: %v", err)
+			}
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+func TestCloudLoggingSink(t *testing.T) {
+	srv := newFakeLoggingServer(t, 1)
+	sink, err := NewCloudLoggingSink(CloudLoggingConfig{
+		Endpoint:   srv.URL,
+		Project:    "test-project",
+		LogName:    "app/requests",
+		BatchCount: 2,
+		BatchDelay: time.Hour,
+		Backoff:    time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("creating sink: %v", err)
+	}
+	logger := New(sink)
+	logger.SetProject("test-project")
+	logger.WithLabels(Fields{"hello": "world"}).WithError(errors.New("oops")).Warn("first")
+	logger.WithDetail("key", "value").Info("second")
+	logger.StartOperation("op", "producer")
+	if err := sink.Close(); err != nil {
+		t.Fatalf("closing sink: %v", err)
+	}
+
+	srv.mu.Lock()
+	if len(srv.requests) != 2 {
+		t.Errorf("unexpected number of batches\nwant: 2\ngot: %d", len(srv.requests))
+	}
+	if srv.attempts != 3 {
+		t.Errorf("failed request not retried\nwant: 3 attempts\ngot: %d", srv.attempts)
+	}
+	req := srv.requests[0]
+	srv.mu.Unlock()
+	if req.LogName != "projects/test-project/logs/app%2Frequests" {
+		t.Errorf("unexpected log name: %s", req.LogName)
+	}
+	if req.Resource == nil || req.Resource.Type != "global" {
+		t.Errorf("unexpected resource: %+v", req.Resource)
+	}
+
+	entries := srv.entries(t)
+	if len(entries) != 3 {
+		t.Fatalf("unexpected number of entries\nwant: 3\ngot: %d", len(entries))
+	}
+	first := entries[0]
+	if first.Severity != SeverityWarning || first.JSONPayload == nil || first.JSONPayload.Message != "first" || first.JSONPayload.Err != "oops" {
+		t.Errorf("entry not mapped: %+v", first)
+	}
+	if first.Labels["hello"] != "world" {
+		t.Errorf("labels not mapped: %v", first.Labels)
+	}
+	if first.SourceLocation == nil || first.SourceLocation.Function == "" {
+		t.Errorf("source location not mapped: %+v", first.SourceLocation)
+	}
+	if _, err := time.Parse(time.RFC3339Nano, first.Timestamp); err != nil {
+		t.Errorf("timestamp not set: %v", err)
+	}
+	if entries[1].JSONPayload == nil || entries[1].JSONPayload.Details["key"] != "value" {
+		t.Errorf("details not mapped: %+v", entries[1])
+	}
+	if entries[2].Operation == nil || !entries[2].Operation.First {
+		t.Errorf("operation not mapped: %+v", entries[2].Operation)
+	}
+}
+
+func TestCloudLoggingBatchDelay(t *testing.T) {
+	srv := newFakeLoggingServer(t, 0)
+	sink, err := NewCloudLoggingSink(CloudLoggingConfig{
+		Endpoint:   srv.URL,
+		LogName:    "projects/test-project/logs/tau",
+		BatchDelay: 10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("creating sink: %v", err)
+	}
+	New(sink).Info("hello")
+	deadline := time.Now().Add(5 * time.Second)
+	for len(srv.entries(t)) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("batch not sent after delay")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestCloudLoggingBatchBytes(t *testing.T) {
+	srv := newFakeLoggingServer(t, 0)
+	w, err := newCloudLoggingWriter(CloudLoggingConfig{
+		Endpoint:   srv.URL,
+		Project:    "test-project",
+		BatchBytes: 10,
+		BatchDelay: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("creating writer: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := w.Write([]byte(`{"jsonPayload":{}}`)); err != nil {
+			t.Fatalf("writing: %v", err)
+		}
+	}
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if len(srv.requests) != 3 {
+		t.Errorf("batches not split by size\nwant: 3\ngot: %d", len(srv.requests))
+	}
+}
+
+func TestCloudLoggingPermanentFailure(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer srv.Close()
+	w, err := newCloudLoggingWriter(CloudLoggingConfig{Endpoint: srv.URL, Project: "test-project", BatchCount: 1})
+	if err != nil {
+		t.Fatalf("creating writer: %v", err)
+	}
+	if _, err := w.Write([]byte(`{}`)); err == nil {
+		t.Error("expected error for forbidden request")
+	}
+	s := NewSink(w)
+	New(s).Info("dropped")
+	if got := s.Dropped(); got != 2 {
+		t.Errorf("unexpected dropped entries\nwant: 2\ngot: %d", got)
+	}
+}
+
+func TestCloudLoggingConfig(t *testing.T) {
+	if _, err := NewCloudLoggingSink(CloudLoggingConfig{}); err == nil {
+		t.Error("expected error without project")
+	}
+}
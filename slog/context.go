@@ -0,0 +1,136 @@
+package slog
+
+import (
+	"context"
+	"fmt"
+)
+
+// DebugCtx sends a message to the logger of the entry in ctx with severity Debug.
+// Arguments are handled in the manner of fmt.Print.
+func DebugCtx(ctx context.Context, v ...interface{}) {
+	e := FromContext(ctx)
+	e.logger.log(e, SeverityDebug, fmt.Sprint(v...), 2)
+}
+
+// DebugfCtx sends a message to the logger of the entry in ctx with severity Debug.
+// Arguments are handled in the manner of fmt.Printf.
+func DebugfCtx(ctx context.Context, format string, v ...interface{}) {
+	e := FromContext(ctx)
+	e.logger.log(e, SeverityDebug, fmt.Sprintf(format, v...), 2)
+}
+
+// InfoCtx sends a message to the logger of the entry in ctx with severity Info.
+// Arguments are handled in the manner of fmt.Print.
+func InfoCtx(ctx context.Context, v ...interface{}) {
+	e := FromContext(ctx)
+	e.logger.log(e, SeverityInfo, fmt.Sprint(v...), 2)
+}
+
+// InfofCtx sends a message to the logger of the entry in ctx with severity Info.
+// Arguments are handled in the manner of fmt.Printf.
+func InfofCtx(ctx context.Context, format string, v ...interface{}) {
+	e := FromContext(ctx)
+	e.logger.log(e, SeverityInfo, fmt.Sprintf(format, v...), 2)
+}
+
+// NoticeCtx sends a message to the logger of the entry in ctx with severity Notice.
+// Arguments are handled in the manner of fmt.Print.
+func NoticeCtx(ctx context.Context, v ...interface{}) {
+	e := FromContext(ctx)
+	e.logger.log(e, SeverityNotice, fmt.Sprint(v...), 2)
+}
+
+// NoticefCtx sends a message to the logger of the entry in ctx with severity Notice.
+// Arguments are handled in the manner of fmt.Printf.
+func NoticefCtx(ctx context.Context, format string, v ...interface{}) {
+	e := FromContext(ctx)
+	e.logger.log(e, SeverityNotice, fmt.Sprintf(format, v...), 2)
+}
+
+// WarnCtx sends a message to the logger of the entry in ctx with severity Warn.
+// Arguments are handled in the manner of fmt.Print.
+func WarnCtx(ctx context.Context, v ...interface{}) {
+	e := FromContext(ctx)
+	e.logger.log(e, SeverityWarning, fmt.Sprint(v...), 2)
+}
+
+// WarnfCtx sends a message to the logger of the entry in ctx with severity Warn.
+// Arguments are handled in the manner of fmt.Printf.
+func WarnfCtx(ctx context.Context, format string, v ...interface{}) {
+	e := FromContext(ctx)
+	e.logger.log(e, SeverityWarning, fmt.Sprintf(format, v...), 2)
+}
+
+// ErrorCtx sends a message to the logger of the entry in ctx with severity Error.
+// Arguments are handled in the manner of fmt.Print.
+func ErrorCtx(ctx context.Context, v ...interface{}) {
+	e := FromContext(ctx)
+	e.logger.log(e, SeverityError, fmt.Sprint(v...), 2)
+}
+
+// ErrorfCtx sends a message to the logger of the entry in ctx with severity Error.
+// Arguments are handled in the manner of fmt.Printf.
+func ErrorfCtx(ctx context.Context, format string, v ...interface{}) {
+	e := FromContext(ctx)
+	e.logger.log(e, SeverityError, fmt.Sprintf(format, v...), 2)
+}
+
+// CriticalCtx sends a message to the logger of the entry in ctx with severity Critical.
+// Arguments are handled in the manner of fmt.Print.
+func CriticalCtx(ctx context.Context, v ...interface{}) {
+	e := FromContext(ctx)
+	e.logger.log(e, SeverityCritical, fmt.Sprint(v...), 2)
+}
+
+// CriticalfCtx sends a message to the logger of the entry in ctx with severity Critical.
+// Arguments are handled in the manner of fmt.Printf.
+func CriticalfCtx(ctx context.Context, format string, v ...interface{}) {
+	e := FromContext(ctx)
+	e.logger.log(e, SeverityCritical, fmt.Sprintf(format, v...), 2)
+}
+
+// AlertCtx sends a message to the logger of the entry in ctx with severity Alert.
+// Arguments are handled in the manner of fmt.Print.
+func AlertCtx(ctx context.Context, v ...interface{}) {
+	e := FromContext(ctx)
+	e.logger.log(e, SeverityAlert, fmt.Sprint(v...), 2)
+}
+
+// AlertfCtx sends a message to the logger of the entry in ctx with severity Alert.
+// Arguments are handled in the manner of fmt.Printf.
+func AlertfCtx(ctx context.Context, format string, v ...interface{}) {
+	e := FromContext(ctx)
+	e.logger.log(e, SeverityAlert, fmt.Sprintf(format, v...), 2)
+}
+
+// EmergencyCtx sends a message to the logger of the entry in ctx with severity Emergency.
+// Arguments are handled in the manner of fmt.Print.
+func EmergencyCtx(ctx context.Context, v ...interface{}) {
+	e := FromContext(ctx)
+	e.logger.log(e, SeverityEmergency, fmt.Sprint(v...), 2)
+}
+
+// EmergencyfCtx sends a message to the logger of the entry in ctx with severity Emergency.
+// Arguments are handled in the manner of fmt.Printf.
+func EmergencyfCtx(ctx context.Context, format string, v ...interface{}) {
+	e := FromContext(ctx)
+	e.logger.log(e, SeverityEmergency, fmt.Sprintf(format, v...), 2)
+}
+
+// FatalCtx sends a message to the logger of the entry in ctx with severity Emergency,
+// then runs hooks, flushes and exits.
+// Arguments are handled in the manner of fmt.Print.
+func FatalCtx(ctx context.Context, v ...interface{}) {
+	e := FromContext(ctx)
+	e.logger.log(e, SeverityEmergency, fmt.Sprint(v...), 2)
+	e.logger.shutdown()
+}
+
+// FatalfCtx sends a message to the logger of the entry in ctx with severity Emergency,
+// then runs hooks, flushes and exits.
+// Arguments are handled in the manner of fmt.Printf.
+func FatalfCtx(ctx context.Context, format string, v ...interface{}) {
+	e := FromContext(ctx)
+	e.logger.log(e, SeverityEmergency, fmt.Sprintf(format, v...), 2)
+	e.logger.shutdown()
+}
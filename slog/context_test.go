@@ -0,0 +1,64 @@
+package slog
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestContextSeverities(t *testing.T) {
+	ctx := context.Background()
+	for level, f := range map[string]func(){
+		"DEBUG":     func() { DebugCtx(ctx, defaultMessage); DebugfCtx(ctx, defaultMessage) },
+		"INFO":      func() { InfoCtx(ctx, defaultMessage); InfofCtx(ctx, defaultMessage) },
+		"NOTICE":    func() { NoticeCtx(ctx, defaultMessage); NoticefCtx(ctx, defaultMessage) },
+		"WARNING":   func() { WarnCtx(ctx, defaultMessage); WarnfCtx(ctx, defaultMessage) },
+		"ERROR":     func() { ErrorCtx(ctx, defaultMessage); ErrorfCtx(ctx, defaultMessage) },
+		"CRITICAL":  func() { CriticalCtx(ctx, defaultMessage); CriticalfCtx(ctx, defaultMessage) },
+		"ALERT":     func() { AlertCtx(ctx, defaultMessage); AlertfCtx(ctx, defaultMessage) },
+		"EMERGENCY": func() { EmergencyCtx(ctx, defaultMessage); EmergencyfCtx(ctx, defaultMessage) },
+	} {
+		f()
+		got := buf.String()
+		buf.Reset()
+		if n := strings.Count(got, `"severity":"`+level+`"`); n != 2 {
+			t.Errorf("unexpected entries with severity %s\nwant: 2\ngot: %d\n%s", level, n, got)
+		}
+	}
+}
+
+func TestLoggerContext(t *testing.T) {
+	if LoggerFromContext(context.Background()) != std {
+		t.Errorf("package-level logger not returned when context has no entry")
+	}
+
+	out := bytes.NewBuffer(make([]byte, 0, 1024))
+	logger := newLogger(out)
+	ctx := NewLoggerContext(context.Background(), logger)
+	if LoggerFromContext(ctx) != logger {
+		t.Errorf("logger not retrieved from context")
+	}
+	InfoCtx(ctx, "to logger")
+	got := out.String()
+	out.Reset()
+	if !strings.Contains(got, `"message":"to logger"`) || buf.Len() != 0 {
+		t.Errorf("entry not written to logger from context: %s", got)
+	}
+	if !strings.Contains(got, `"function":"github.com/ParticleHealth/tau/slog.TestLoggerContext"`) {
+		t.Errorf("source not set to caller: %s", got)
+	}
+
+	// An entry already in the context keeps its metadata when the logger is replaced.
+	entry := std.entry().WithDetail("request", "abc")
+	ctx = NewLoggerContext(NewContext(context.Background(), entry), logger)
+	WarnfCtx(ctx, "request %d", 1)
+	got = out.String()
+	out.Reset()
+	if !strings.Contains(got, `"message":"request 1"`) || !strings.Contains(got, `"request":"abc"`) {
+		t.Errorf("entry metadata not kept with logger from context: %s", got)
+	}
+	if entry.logger != std {
+		t.Errorf("entry in parent context changed")
+	}
+}
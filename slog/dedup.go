@@ -0,0 +1,201 @@
+package slog
+
+import (
+	"fmt"
+	"hash/fnv"
+	"io"
+	"sync"
+	"time"
+)
+
+// Details added to the summary of a de-duplicated entry.
+const (
+	DetailRepeated  = "repeated"
+	DetailFirstSeen = "first_seen"
+	DetailLastSeen  = "last_seen"
+)
+
+// maxDedupEntries tracked at once, entries seen while full are written without de-duplication.
+const maxDedupEntries = 4096
+
+// dedup suppresses repeats of an entry, summarizing them once per window.
+type dedup struct {
+	window time.Duration
+	fields []string
+	now    func() time.Time
+
+	mu   sync.Mutex // guards seen
+	seen map[uint64]*dedupEntry
+}
+
+// dedupEntry seen within the current window. first and last are when it was first and last repeated.
+type dedupEntry struct {
+	entry       Entry
+	first, last time.Time
+	repeated    int
+	timer       *time.Timer
+}
+
+func newDedup(window time.Duration, fields []string) *dedup {
+	return &dedup{window: window, fields: fields, now: time.Now, seen: make(map[uint64]*dedupEntry)}
+}
+
+// fingerprint of an entry from its severity, message, error, trace, operation and selected fields.
+func (d *dedup) fingerprint(e *Entry) uint64 {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%d\x00%s\x00%s\x00%s", e.Severity, e.Message, e.Err, e.Trace)
+	if e.Operation != nil {
+		fmt.Fprintf(h, "\x00%s", e.Operation.ID)
+	}
+	for _, k := range d.fields {
+		_, _ = io.WriteString(h, "\x00")
+		if v, ok := fieldValue(e, k); ok {
+			fmt.Fprint(h, v)
+		}
+	}
+	return h.Sum64()
+}
+
+// fieldValue of a key from typed fields, details or labels in that order.
+func fieldValue(e *Entry, k string) (interface{}, bool) {
+	for i := len(e.fields) - 1; i >= 0; i-- {
+		if e.fields[i].Key == k {
+			return e.fields[i].Value(), true
+		}
+	}
+	if v, ok := e.Details[k]; ok {
+		return v, true
+	}
+	v, ok := e.Labels[k]
+	return v, ok
+}
+
+// suppress reports whether the entry repeats one seen within the window, otherwise it starts a new window.
+// Entries with a protoPayload, such as audit logs, are never suppressed. Must be called with the logger mutex held.
+func (d *dedup) suppress(l *Logger, e *Entry) bool {
+	if e.ProtoPayload != nil {
+		return false
+	}
+	key := d.fingerprint(e)
+	now := d.now()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if seen, ok := d.seen[key]; ok {
+		if seen.repeated == 0 {
+			seen.first = now
+		}
+		seen.repeated++
+		seen.last = now
+		return true
+	}
+	if len(d.seen) >= maxDedupEntries {
+		return false
+	}
+	seen := &dedupEntry{entry: *e}
+	seen.timer = time.AfterFunc(d.window, func() { l.expire(d, key, seen) })
+	d.seen[key] = seen
+	return false
+}
+
+// next summary of the entry as its window closes, starting another window if it was repeated.
+// Returns nil, forgetting the entry, if it was not repeated or is no longer current.
+func (d *dedup) next(key uint64, seen *dedupEntry) *Entry {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.seen[key] != seen {
+		return nil
+	}
+	if seen.repeated == 0 {
+		delete(d.seen, key)
+		return nil
+	}
+	s := seen.summary()
+	seen.repeated = 0
+	seen.timer.Reset(d.window)
+	return s
+}
+
+// drain all entries, stopping their timers.
+func (d *dedup) drain() []*dedupEntry {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var all []*dedupEntry
+	for key, seen := range d.seen {
+		seen.timer.Stop()
+		delete(d.seen, key)
+		all = append(all, seen)
+	}
+	return all
+}
+
+// summary of a repeated entry, with the repeat count and when it was first and last seen added to the details.
+func (seen *dedupEntry) summary() *Entry {
+	s := seen.entry
+	details := seen.entry.mergedDetails()
+	s.Details = make(Fields, len(details)+3)
+	for k, v := range details {
+		s.Details[k] = v
+	}
+	s.fields = nil
+	s.Details[DetailRepeated] = seen.repeated
+	s.Details[DetailFirstSeen] = seen.first.Format(time.RFC3339Nano)
+	s.Details[DetailLastSeen] = seen.last.Format(time.RFC3339Nano)
+	return &s
+}
+
+// expire the window of an entry, writing a summary if it was repeated.
+func (l *Logger) expire(d *dedup, key uint64, seen *dedupEntry) {
+	l.mu.Lock()
+	s := d.next(key, seen)
+	if s == nil {
+		l.mu.Unlock()
+		return
+	}
+	w := l.write(s)
+	w.repeats = true
+	l.mu.Unlock()
+	l.finish(w)
+}
+
+// flushDedup writes summaries of all repeated entries, closing their windows.
+func (l *Logger) flushDedup(d *dedup) {
+	l.mu.Lock()
+	var ws []written
+	for _, seen := range d.drain() {
+		if seen.repeated > 0 {
+			w := l.write(seen.summary())
+			w.repeats = true
+			ws = append(ws, w)
+		}
+	}
+	l.mu.Unlock()
+	for _, w := range ws {
+		l.finish(w)
+	}
+}
+
+// SetDedup window in which repeats of an entry are suppressed. Entries are the same if their severity, message,
+// error, trace, operation ID and the given fields match. Each window after the first entry, or on Flush, one entry
+// is written with the number of repeats in that window and when they were first and last seen, so a steady stream
+// of repeats is summarized once per window. Up to 4096 distinct entries are tracked, others are written as is.
+// Entries with a protoPayload, such as audit logs, are always written. A window of 0 disables de-duplication.
+//
+// Suppressed entries are counted in metrics as they are logged, and the summary is not counted again.
+// Hooks fire for the first entry and the summary only.
+func (l *Logger) SetDedup(window time.Duration, fields ...string) {
+	l.mu.Lock()
+	old := l.dedup
+	l.dedup = nil
+	if window > 0 {
+		l.dedup = newDedup(window, fields)
+	}
+	l.mu.Unlock()
+	if old != nil {
+		l.flushDedup(old)
+	}
+}
+
+// SetDedup window in which the package-level logger suppresses repeats of an entry.
+func SetDedup(window time.Duration, fields ...string) {
+	std.SetDedup(window, fields...)
+}
@@ -0,0 +1,198 @@
+package slog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// taken entries written to out, resetting it.
+func taken(t *testing.T, out *bytes.Buffer) []Entry {
+	t.Helper()
+	entries := decodeEntries(t, out.Bytes())
+	out.Reset()
+	return entries
+}
+
+func TestDedup(t *testing.T) {
+	out := bytes.NewBuffer(make([]byte, 0, 4096))
+	logger := newLogger(out)
+	logger.SetDedup(time.Hour, "patient")
+	e := logger.entry().WithError(errors.New("unavailable"))
+
+	for i := 0; i < 5; i++ {
+		e.WithDetail("patient", "abc").WithDetail("attempt", i).Error("fetch failed")
+	}
+	e.WithDetail("patient", "def").Error("fetch failed")
+	e.With(String("patient", "abc")).Warn("fetch failed")
+	if got := taken(t, out); len(got) != 3 {
+		t.Fatalf("repeats not suppressed\nwant: 3 entries\ngot: %d", len(got))
+	}
+
+	if err := logger.Flush(); err != nil {
+		t.Fatalf("flushing: %v", err)
+	}
+	got := taken(t, out)
+	if len(got) != 1 {
+		t.Fatalf("unexpected number of summaries\nwant: 1\ngot: %d", len(got))
+	}
+	s := got[0]
+	if s.Message != "fetch failed" || s.Err != "unavailable" || s.Severity != SeverityError {
+		t.Errorf("unexpected summary: %+v", s)
+	}
+	if s.Details[DetailRepeated] != float64(4) || s.Details["attempt"] != float64(0) {
+		t.Errorf("unexpected summary details: %v", s.Details)
+	}
+	first, err := time.Parse(time.RFC3339Nano, s.Details[DetailFirstSeen].(string))
+	if err != nil {
+		t.Fatalf("parsing first seen: %v", err)
+	}
+	last, err := time.Parse(time.RFC3339Nano, s.Details[DetailLastSeen].(string))
+	if err != nil {
+		t.Fatalf("parsing last seen: %v", err)
+	}
+	if last.Before(first) {
+		t.Errorf("last seen %v before first seen %v", last, first)
+	}
+
+	// Flushing closes the window so the next occurrence is written.
+	e.WithDetail("patient", "abc").Error("fetch failed")
+	if got := taken(t, out); len(got) != 1 {
+		t.Errorf("window not closed by flush\nwant: 1 entry\ngot: %d", len(got))
+	}
+}
+
+func TestDedupWindow(t *testing.T) {
+	out := &blockingWriter{release: make(chan struct{})}
+	close(out.release)
+	logger := newLogger(out)
+	logger.SetDedup(20 * time.Millisecond)
+	e := logger.entry()
+
+	e.Info("once")
+	e.Info("twice")
+	e.Info("twice")
+	deadline := time.Now().Add(5 * time.Second)
+	for strings.Count(out.String(), "\n") < 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("summary not written when window closed:\n%s", out.String())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	time.Sleep(40 * time.Millisecond)
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 || strings.Contains(lines[0], DetailRepeated) || !strings.Contains(lines[2], `"repeated":1`) {
+		t.Errorf("unexpected entries:\n%s", out.String())
+	}
+}
+
+func TestDedupDisable(t *testing.T) {
+	out := bytes.NewBuffer(make([]byte, 0, 4096))
+	logger := newLogger(out)
+	logger.SetDedup(time.Hour)
+	e := logger.entry()
+
+	e.Info("repeat")
+	e.Info("repeat")
+	logger.SetDedup(0)
+	if got := taken(t, out); len(got) != 2 || got[1].Details[DetailRepeated] != float64(1) {
+		t.Fatalf("summary not written when disabled: %+v", got)
+	}
+	e.Info("repeat")
+	e.Info("repeat")
+	if got := taken(t, out); len(got) != 2 {
+		t.Errorf("repeats suppressed when disabled\nwant: 2 entries\ngot: %d", len(got))
+	}
+}
+
+func TestDedupKeepsAuditAndTraces(t *testing.T) {
+	out := bytes.NewBuffer(make([]byte, 0, 4096))
+	logger := newLogger(out)
+	logger.SetDedup(time.Hour)
+	audit := NewAuditLogger(logger)
+	key := StaticKey([]byte("secret"))
+	audit.SetIntegrity(key)
+	for i := 0; i < 3; i++ {
+		if err := audit.Log(context.Background(), testAuditEvent()); err != nil {
+			t.Fatalf("logging audit event: %v", err)
+		}
+	}
+	var logs []*AuditLog
+	for _, r := range decodeAuditLogs(t, out.Bytes()) {
+		logs = append(logs, r.ProtoPayload)
+	}
+	out.Reset()
+	if len(logs) != 3 {
+		t.Fatalf("audit entries suppressed\nwant: 3\ngot: %d", len(logs))
+	}
+	if report, err := VerifyAuditChain(logs, key); err != nil || !report.OK() {
+		t.Errorf("audit chain broken by de-duplication: %+v, %v", report, err)
+	}
+
+	for _, trace := range []string{"t1", "t2", "t1"} {
+		e := logger.entry()
+		e.Trace = trace
+		e.WithOperation("op-"+trace, "job").Error("failed")
+	}
+	if got := taken(t, out); len(got) != 2 {
+		t.Errorf("entries for different traces suppressed\nwant: 2\ngot: %d", len(got))
+	}
+}
+
+func TestDedupSummarizesEachWindow(t *testing.T) {
+	out := bytes.NewBuffer(make([]byte, 0, 4096))
+	logger := newLogger(out)
+	logger.SetDedup(time.Hour)
+	d := logger.dedup
+	e := logger.entry()
+
+	e.Error("repeat")
+	var key uint64
+	var seen *dedupEntry
+	for key, seen = range d.seen {
+	}
+	// A steady stream of repeats is summarized each time the window closes.
+	for window := 0; window < 2; window++ {
+		for i := 0; i < 3; i++ {
+			e.Error("repeat")
+		}
+		logger.expire(d, key, seen)
+		got := taken(t, out)
+		if window == 0 {
+			got = got[1:]
+		}
+		if len(got) != 1 || got[0].Details[DetailRepeated] != float64(3) {
+			t.Fatalf("window %d not summarized: %+v", window, got)
+		}
+	}
+	// A window without repeats closes without a summary.
+	logger.expire(d, key, seen)
+	if got := taken(t, out); len(got) != 0 || len(d.seen) != 0 {
+		t.Errorf("quiet window not closed: %+v", got)
+	}
+
+	// Suppressed repeats are counted once, the summaries are not counted again.
+	if got := logger.Counts().Severity["ERROR"]; got != 7 {
+		t.Errorf("unexpected error count\nwant: 7\ngot: %d", got)
+	}
+}
+
+func TestDedupLimit(t *testing.T) {
+	out := bytes.NewBuffer(make([]byte, 0, 4096))
+	logger := newLogger(out)
+	logger.SetDedup(time.Hour)
+	e := logger.entry()
+	for i := 0; i < maxDedupEntries; i++ {
+		e.Infof("message %d", i)
+	}
+	out.Reset()
+	e.Info("untracked")
+	e.Info("untracked")
+	if got := taken(t, out); len(got) != 2 || len(logger.dedup.seen) != maxDedupEntries {
+		t.Errorf("entries beyond the limit tracked\nwant: 2 entries\ngot: %d, tracking %d", len(got), len(logger.dedup.seen))
+	}
+	logger.SetDedup(0)
+}
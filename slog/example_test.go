@@ -3,6 +3,7 @@ package slog
 import (
 	"context"
 	"errors"
+	"os"
 
 	"go.opencensus.io/trace"
 )
@@ -21,7 +22,7 @@ func ExampleStartOperation() {
 	entry := StartOperation(id, producer)
 	entry.Info("entry logged under new operation")
 
-	entry.WithOperation(id, producer)
+	entry = WithOperation(id, producer)
 	entry.Info("entry logged under existing operation")
 
 	entry.EndOperation()
@@ -59,3 +60,44 @@ func ExampleWithSpan() {
 	entry := WithSpan(span.SpanContext())
 	entry.Info("entry with span")
 }
+
+func ExampleDetectResource() {
+	r, err := DetectResource(context.Background(), true)
+	if err != nil {
+		WithError(err).Warn("could not detect resource")
+	}
+	SetResource(r)
+	Info("entry with resource labels")
+}
+
+func ExampleRunTask() {
+	err := RunTask(func(entry *Entry) error {
+		entry.Info("entry logged as part of the task attempt")
+		return nil
+	})
+	if err != nil {
+		os.Exit(1)
+	}
+}
+
+func ExampleNew() {
+	stdout := NewSink(os.Stdout)
+	stdout.SetMaxSeverity(SeverityWarning)
+	stderr := NewSink(os.Stderr)
+	stderr.SetMinSeverity(SeverityError)
+	logger := New(stdout, stderr)
+	logger.Info("entry written to stdout")
+	logger.Error("entry written to stderr")
+
+	// Sinks of a logger with several are written asynchronously, so flush them before exiting.
+	_ = logger.Flush()
+}
+
+func ExampleNewLoggerContext() {
+	logger := New(NewSink(os.Stderr))
+	ctx := NewLoggerContext(context.Background(), logger)
+
+	// Libraries log with the context they are passed, so use the logger chosen by the application.
+	InfoCtx(ctx, "entry written to logger from context")
+	LoggerFromContext(ctx).Info("entry written to logger from context")
+}
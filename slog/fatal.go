@@ -0,0 +1,164 @@
+package slog
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// Defaults used by Fatal unless changed.
+const (
+	defaultExitCode     = 1
+	defaultFatalTimeout = 5 * time.Second
+)
+
+// fatalConfig for a Logger, guarded by its mutex.
+type fatalConfig struct {
+	hooks   []func(ctx context.Context)
+	timeout time.Duration
+	code    int
+	exit    func(code int)
+}
+
+// OnFatal registers f to run before the process exits from a call to Fatal.
+// Hooks run concurrently and the context is cancelled once the fatal timeout passes.
+func (l *Logger) OnFatal(f func(ctx context.Context)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.fatal.hooks = append(l.fatal.hooks, f)
+}
+
+// OnFatal registers f to run before the process exits from a call to the package-level Fatal.
+// Hooks run concurrently and the context is cancelled once the fatal timeout passes.
+func OnFatal(f func(ctx context.Context)) {
+	std.OnFatal(f)
+}
+
+// SetFatalTimeout for hooks registered with OnFatal to finish, defaults to 5 seconds.
+func (l *Logger) SetFatalTimeout(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.fatal.timeout = d
+}
+
+// SetFatalTimeout for hooks registered with the package-level OnFatal to finish, defaults to 5 seconds.
+func SetFatalTimeout(d time.Duration) {
+	std.SetFatalTimeout(d)
+}
+
+// SetExitCode used by Fatal, defaults to 1.
+func (l *Logger) SetExitCode(code int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.fatal.code = code
+}
+
+// SetExitCode used by the package-level Fatal, defaults to 1.
+func SetExitCode(code int) {
+	std.SetExitCode(code)
+}
+
+// SetExitFunc called by Fatal in place of os.Exit, allowing tests to intercept it.
+func (l *Logger) SetExitFunc(exit func(code int)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.fatal.exit = exit
+}
+
+// SetExitFunc called by the package-level Fatal in place of os.Exit, allowing tests to intercept it.
+func SetExitFunc(exit func(code int)) {
+	std.SetExitFunc(exit)
+}
+
+// shutdown after a fatal log by running hooks, flushing sinks and exiting.
+func (l *Logger) shutdown() {
+	l.mu.Lock()
+	cfg := l.fatal
+	l.mu.Unlock()
+	if cfg.timeout <= 0 {
+		cfg.timeout = defaultFatalTimeout
+	}
+	if cfg.code == 0 {
+		cfg.code = defaultExitCode
+	}
+	if cfg.exit == nil {
+		cfg.exit = os.Exit
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.timeout)
+	defer cancel()
+	var wg sync.WaitGroup
+	for _, f := range cfg.hooks {
+		wg.Add(1)
+		go func(f func(ctx context.Context)) {
+			defer wg.Done()
+			defer func() {
+				if r := recover(); r != nil {
+					fmt.Fprintln(os.Stderr, "fatal hook panicked:", r)
+				}
+			}()
+			f(ctx)
+		}(f)
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		fmt.Fprintln(os.Stderr, "fatal hooks did not finish:", ctx.Err())
+	}
+
+	if err := l.Flush(); err != nil {
+		fmt.Fprintln(os.Stderr, "could not flush logs:", err)
+	}
+	cfg.exit(cfg.code)
+}
+
+// Fatal sends a message to the logger with severity Emergency, then runs hooks, flushes and exits.
+// Arguments are handled in the manner of fmt.Print.
+func (l *Logger) Fatal(v ...interface{}) {
+	l.log(base, SeverityEmergency, fmt.Sprint(v...), 2)
+	l.shutdown()
+}
+
+// Fatal sends a message to the default logger with severity Emergency, then runs hooks, flushes and exits.
+// Arguments are handled in the manner of fmt.Print.
+func Fatal(v ...interface{}) {
+	std.log(base, SeverityEmergency, fmt.Sprint(v...), 2)
+	std.shutdown()
+}
+
+// Fatal sends a message to the logger associated with this entry with severity Emergency,
+// then runs hooks, flushes and exits.
+// Arguments are handled in the manner of fmt.Print.
+func (e *Entry) Fatal(v ...interface{}) {
+	e.logger.log(e, SeverityEmergency, fmt.Sprint(v...), 2)
+	e.logger.shutdown()
+}
+
+// Fatalf sends a message to the logger with severity Emergency, then runs hooks, flushes and exits.
+// Arguments are handled in the manner of fmt.Printf.
+func (l *Logger) Fatalf(format string, v ...interface{}) {
+	l.log(base, SeverityEmergency, fmt.Sprintf(format, v...), 2)
+	l.shutdown()
+}
+
+// Fatalf sends a message to the default logger with severity Emergency, then runs hooks, flushes and exits.
+// Arguments are handled in the manner of fmt.Printf.
+func Fatalf(format string, v ...interface{}) {
+	std.log(base, SeverityEmergency, fmt.Sprintf(format, v...), 2)
+	std.shutdown()
+}
+
+// Fatalf sends a message to the logger associated with this entry with severity Emergency,
+// then runs hooks, flushes and exits.
+// Arguments are handled in the manner of fmt.Printf.
+func (e *Entry) Fatalf(format string, v ...interface{}) {
+	e.logger.log(e, SeverityEmergency, fmt.Sprintf(format, v...), 2)
+	e.logger.shutdown()
+}
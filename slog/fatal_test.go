@@ -0,0 +1,94 @@
+package slog
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flushRecorder records whether it was flushed.
+type flushRecorder struct {
+	bytes.Buffer
+	flushed bool
+}
+
+func (f *flushRecorder) Flush() error {
+	f.flushed = true
+	return nil
+}
+
+func TestFatal(t *testing.T) {
+	out := &flushRecorder{}
+	logger := newLogger(out)
+	var hooks int32
+	logger.OnFatal(func(ctx context.Context) { atomic.AddInt32(&hooks, 1) })
+	logger.OnFatal(func(ctx context.Context) { panic("hook failure") })
+	logger.SetExitCode(3)
+	code := -1
+	logger.SetExitFunc(func(c int) { code = c })
+
+	logger.WithError(context.Canceled).Fatalf("shutting down: %d", 1)
+	got := out.String()
+	if !strings.Contains(got, `"severity":"EMERGENCY"`) || !strings.Contains(got, "shutting down: 1") {
+		t.Errorf("fatal not logged at emergency: %s", got)
+	}
+	if !strings.Contains(got, "exception") {
+		t.Errorf("fatal missing stack: %s", got)
+	}
+	if atomic.LoadInt32(&hooks) != 1 {
+		t.Error("fatal hook not run")
+	}
+	if !out.flushed {
+		t.Error("sinks not flushed")
+	}
+	if code != 3 {
+		t.Errorf("unexpected exit code\nwant: 3\ngot: %d", code)
+	}
+}
+
+func TestFatalTimeout(t *testing.T) {
+	logger := newLogger(bytes.NewBuffer(make([]byte, 0, 1024)))
+	logger.SetFatalTimeout(10 * time.Millisecond)
+	logger.OnFatal(func(ctx context.Context) { <-ctx.Done() })
+	block := make(chan struct{})
+	defer close(block)
+	logger.OnFatal(func(ctx context.Context) { <-block })
+	code := -1
+	logger.SetExitFunc(func(c int) { code = c })
+
+	done := make(chan struct{})
+	go func() {
+		logger.Fatal("shutting down")
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("fatal blocked on hooks")
+	}
+	if code != defaultExitCode {
+		t.Errorf("unexpected exit code\nwant: %d\ngot: %d", defaultExitCode, code)
+	}
+}
+
+func TestFatalVariants(t *testing.T) {
+	code := 0
+	SetExitFunc(func(c int) { code += c })
+	defer SetExitFunc(nil)
+	for _, f := range []func(){
+		func() { Fatal(defaultMessage) },
+		func() { Fatalf(defaultMessage) },
+		func() { base.Fatal(defaultMessage) },
+		func() { base.Fatalf(defaultMessage) },
+		func() { std.Fatal(defaultMessage) },
+		func() { std.Fatalf(defaultMessage) },
+	} {
+		subTestSeverity(t, "EMERGENCY", f)
+	}
+	if code != 6 {
+		t.Errorf("exit not called for every variant\nwant: 6\ngot: %d", code)
+	}
+}
@@ -0,0 +1,366 @@
+package slog
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+// FieldKind of value held by a Field.
+type FieldKind uint8
+
+// Kinds of Field.
+const (
+	KindAny FieldKind = iota
+	KindString
+	KindInt64
+	KindFloat64
+	KindBool
+	KindDuration
+	KindTime
+	KindLabel
+)
+
+// Field is a typed label or detail added with With and Log, avoiding a map allocation per call.
+type Field struct {
+	Key   string
+	Kind  FieldKind
+	num   int64
+	str   string
+	iface interface{}
+}
+
+// String detail.
+func String(k, v string) Field {
+	return Field{Key: k, Kind: KindString, str: v}
+}
+
+// Int detail.
+func Int(k string, v int) Field {
+	return Field{Key: k, Kind: KindInt64, num: int64(v)}
+}
+
+// Int64 detail.
+func Int64(k string, v int64) Field {
+	return Field{Key: k, Kind: KindInt64, num: v}
+}
+
+// Float64 detail.
+func Float64(k string, v float64) Field {
+	return Field{Key: k, Kind: KindFloat64, num: int64(math.Float64bits(v))}
+}
+
+// Bool detail.
+func Bool(k string, v bool) Field {
+	f := Field{Key: k, Kind: KindBool}
+	if v {
+		f.num = 1
+	}
+	return f
+}
+
+// Duration detail, encoded as a string such as "1.5s".
+func Duration(k string, v time.Duration) Field {
+	return Field{Key: k, Kind: KindDuration, num: int64(v)}
+}
+
+// Time detail.
+func Time(k string, v time.Time) Field {
+	return Field{Key: k, Kind: KindTime, iface: v}
+}
+
+// Any detail, for values without a typed constructor.
+func Any(k string, v interface{}) Field {
+	return Field{Key: k, Kind: KindAny, iface: v}
+}
+
+// Label rather than a detail, which Cloud Logging indexes for filtering.
+func Label(k, v string) Field {
+	return Field{Key: k, Kind: KindLabel, str: v}
+}
+
+// Err sets the error of the entry as WithError does, the key is ignored.
+func Err(err error) Field {
+	return Field{Key: "error", Kind: KindAny, iface: errField{err}}
+}
+
+// errField marks an error passed to Err.
+type errField struct {
+	err error
+}
+
+// Value of the field with its Go type, such as int64 for Int or time.Duration for Duration.
+func (f Field) Value() interface{} {
+	switch f.Kind {
+	case KindString, KindLabel:
+		return f.str
+	case KindInt64:
+		return f.num
+	case KindFloat64:
+		return math.Float64frombits(uint64(f.num))
+	case KindBool:
+		return f.num == 1
+	case KindDuration:
+		return time.Duration(f.num)
+	default:
+		return f.iface
+	}
+}
+
+// String of the field value as it would be given by fmt.Sprint.
+func (f Field) String() string {
+	if f.Kind == KindString || f.Kind == KindLabel {
+		return f.str
+	}
+	return fmt.Sprint(f.Value())
+}
+
+// With typed fields. Will create a child entry.
+func (e *Entry) With(fields ...Field) *Entry {
+	// Labels and details are only read, so unlike clone the maps are shared with the parent.
+	next := *e
+	c := &next
+	for _, f := range fields {
+		if ef, ok := f.iface.(errField); ok {
+			c.Err = ""
+			if ef.err != nil {
+				c.Err = ef.err.Error()
+			}
+			continue
+		}
+		// The full slice expression keeps children from sharing a backing array.
+		c.fields = append(c.fields[:len(c.fields):len(c.fields)], f)
+	}
+	return c
+}
+
+// With typed fields. Will create a child entry.
+func With(fields ...Field) *Entry {
+	return std.entry().With(fields...)
+}
+
+// With typed fields. Will create a child entry.
+func (l *Logger) With(fields ...Field) *Entry {
+	return l.entry().With(fields...)
+}
+
+// Fields added with With, in the order they were added.
+func (e *Entry) Fields() []Field {
+	return e.fields
+}
+
+// Log sends a message to the logger associated with this entry with the given severity and fields.
+func (e *Entry) Log(s Severity, m string, fields ...Field) {
+	if len(fields) > 0 {
+		e = e.With(fields...)
+	}
+	e.logger.log(e, s, m, 2)
+}
+
+// Log sends a message to the default logger with the given severity and fields.
+func Log(s Severity, m string, fields ...Field) {
+	e := base
+	if len(fields) > 0 {
+		e = std.entry().With(fields...)
+	}
+	std.log(e, s, m, 2)
+}
+
+// Log sends a message to the logger with the given severity and fields.
+func (l *Logger) Log(s Severity, m string, fields ...Field) {
+	e := base
+	if len(fields) > 0 {
+		e = l.entry().With(fields...)
+	}
+	l.log(e, s, m, 2)
+}
+
+// mergeLabelFields into a copy of labels, leaving the original untouched.
+func mergeLabelFields(labels map[string]string, fields []Field) map[string]string {
+	var m map[string]string
+	for _, f := range fields {
+		if f.Kind != KindLabel {
+			continue
+		}
+		if m == nil {
+			m = make(map[string]string, len(labels)+1)
+			for k, v := range labels {
+				m[k] = v
+			}
+		}
+		m[f.Key] = f.str
+	}
+	if m == nil {
+		return labels
+	}
+	return m
+}
+
+// mergedDetails of the entry with fields added with With, for encoders that need a single map.
+func (e *Entry) mergedDetails() Fields {
+	if len(e.fields) == 0 {
+		return e.Details
+	}
+	var m Fields
+	for _, f := range e.fields {
+		if f.Kind == KindLabel {
+			continue
+		}
+		if m == nil {
+			m = make(Fields, len(e.Details)+len(e.fields))
+			for k, v := range e.Details {
+				m[k] = v
+			}
+		}
+		m[f.Key] = f.Value()
+	}
+	if m == nil {
+		return e.Details
+	}
+	return m
+}
+
+// typedEntry encodes typed fields alongside details without merging them into a map first.
+// Detail values are normalized as they are encoded.
+type typedEntry struct {
+	*Entry
+	Details *typedDetails `json:"details,omitempty"`
+}
+
+// newTypedEntry for an entry, details are left out when there are none.
+func newTypedEntry(e *Entry) typedEntry {
+	t := typedEntry{Entry: e}
+	for _, f := range e.fields {
+		if f.Kind != KindLabel {
+			t.Details = &typedDetails{details: e.Details, fields: e.fields, norm: e.norm}
+			break
+		}
+	}
+	if t.Details == nil && len(e.Details) > 0 {
+		t.Details = &typedDetails{details: e.Details, norm: e.norm}
+	}
+	return t
+}
+
+// typedDetails are details with fields taking precedence over details of the same key.
+type typedDetails struct {
+	details Fields
+	fields  []Field
+	norm    normalizer
+}
+
+// MarshalJSON of details as a single object.
+func (d *typedDetails) MarshalJSON() ([]byte, error) {
+	buf := make([]byte, 0, 256)
+	buf = append(buf, '{')
+	seen := func(k string, from int) bool {
+		for _, f := range d.fields[from:] {
+			if f.Kind != KindLabel && f.Key == k {
+				return true
+			}
+		}
+		return false
+	}
+	keys := make([]string, 0, len(d.details))
+	for k := range d.details {
+		if !seen(k, 0) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	var err error
+	for _, k := range keys {
+		if len(buf) > 1 {
+			buf = append(buf, ',')
+		}
+		buf = appendJSONString(buf, k)
+		buf = append(buf, ':')
+		if buf, err = appendJSONValue(buf, d.norm.value(d.details[k])); err != nil {
+			return nil, err
+		}
+	}
+	for i, f := range d.fields {
+		if f.Kind == KindLabel || seen(f.Key, i+1) {
+			continue
+		}
+		if len(buf) > 1 {
+			buf = append(buf, ',')
+		}
+		buf = appendJSONString(buf, f.Key)
+		buf = append(buf, ':')
+		if buf, err = f.appendJSON(buf, d.norm); err != nil {
+			return nil, err
+		}
+	}
+	return append(buf, '}'), nil
+}
+
+// appendJSON of the field value to buf, normalizing values of any kind.
+func (f Field) appendJSON(buf []byte, norm normalizer) ([]byte, error) {
+	switch f.Kind {
+	case KindString:
+		return appendJSONString(buf, f.str), nil
+	case KindInt64:
+		return strconv.AppendInt(buf, f.num, 10), nil
+	case KindDuration:
+		return appendJSONString(buf, time.Duration(f.num).String()), nil
+	case KindBool:
+		return strconv.AppendBool(buf, f.num == 1), nil
+	case KindFloat64:
+		v := math.Float64frombits(uint64(f.num))
+		if s, ok := nonFinite(v); ok {
+			return appendJSONString(buf, s), nil
+		}
+		return strconv.AppendFloat(buf, v, 'g', -1, 64), nil
+	default:
+		return appendJSONValue(buf, norm.value(f.Value()))
+	}
+}
+
+// appendJSONValue of any value using encoding/json.
+func appendJSONValue(buf []byte, v interface{}) ([]byte, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return append(buf, b...), nil
+}
+
+// appendJSONString quoted and escaped to buf. Invalid UTF-8 is replaced as encoding/json does.
+func appendJSONString(buf []byte, s string) []byte {
+	const hex = "0123456789abcdef"
+	buf = append(buf, '"')
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c < utf8.RuneSelf {
+			switch {
+			case c == '"' || c == '\\':
+				buf = append(buf, '\\', c)
+			case c == '\n':
+				buf = append(buf, '\\', 'n')
+			case c == '\r':
+				buf = append(buf, '\\', 'r')
+			case c == '\t':
+				buf = append(buf, '\\', 't')
+			case c < 0x20:
+				buf = append(buf, '\\', 'u', '0', '0', hex[c>>4], hex[c&0xf])
+			default:
+				buf = append(buf, c)
+			}
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			buf = append(buf, `\ufffd`...)
+		} else {
+			buf = append(buf, s[i:i+size]...)
+		}
+		i += size
+	}
+	return append(buf, '"')
+}
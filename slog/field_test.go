@@ -0,0 +1,144 @@
+package slog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFieldValues(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		f    Field
+		want interface{}
+		str  string
+	}{
+		{String("k", "v"), "v", "v"},
+		{Int("k", 42), int64(42), "42"},
+		{Int64("k", -7), int64(-7), "-7"},
+		{Float64("k", 1.5), 1.5, "1.5"},
+		{Bool("k", true), true, "true"},
+		{Bool("k", false), false, "false"},
+		{Duration("k", 1500*time.Millisecond), 1500 * time.Millisecond, "1.5s"},
+		{Time("k", now), now, now.String()},
+		{Any("k", []int{1, 2}), []int{1, 2}, "[1 2]"},
+		{Label("k", "v"), "v", "v"},
+	}
+	for _, tt := range tests {
+		if diff := cmp.Diff(tt.want, tt.f.Value()); diff != "" {
+			t.Errorf("unexpected value for kind %d:\n%s", tt.f.Kind, diff)
+		}
+		if got := tt.f.String(); got != tt.str {
+			t.Errorf("unexpected string for kind %d\nwant: %s\ngot: %s", tt.f.Kind, tt.str, got)
+		}
+	}
+}
+
+func TestWith(t *testing.T) {
+	out := bytes.NewBuffer(make([]byte, 0, 1024))
+	logger := newLogger(out)
+	logger.SetLabels(Fields{"service": "svc"})
+	parent := logger.WithLabels(Fields{"team": "records"}).WithDetail("existing", "yes")
+	e := parent.With(String("patient", "abc"), Int("count", 3), Duration("took", time.Second), Label("tenant", "t1"), Err(errors.New("failed")))
+	sibling := parent.With(Bool("other", true))
+	e.Log(SeverityWarning, "with fields", Float64("score", 0.5))
+
+	var got Entry
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("decoding entry: %v\n%s", err, out)
+	}
+	if got.Severity != SeverityWarning || got.Message != "with fields" || got.Err != "failed" {
+		t.Errorf("unexpected entry: %+v", got)
+	}
+	wantLabels := map[string]string{"service": "svc", "team": "records", "tenant": "t1"}
+	if diff := cmp.Diff(wantLabels, got.Labels); diff != "" {
+		t.Errorf("unexpected labels:\n%s", diff)
+	}
+	wantDetails := Fields{"existing": "yes", "patient": "abc", "count": 3.0, "took": "1s", "score": 0.5}
+	if diff := cmp.Diff(wantDetails, got.Details); diff != "" {
+		t.Errorf("unexpected details:\n%s", diff)
+	}
+
+	if len(parent.Fields()) != 0 || len(parent.Details) != 1 || len(parent.Labels) != 1 || parent.Err != "" {
+		t.Errorf("parent entry modified: %+v", parent)
+	}
+	if len(e.Fields()) != 4 || len(e.Details) != 1 || len(e.Labels) != 1 {
+		t.Errorf("entry modified by logging: %+v", e)
+	}
+	if diff := cmp.Diff([]Field{Bool("other", true)}, sibling.Fields(), cmp.AllowUnexported(Field{})); diff != "" {
+		t.Errorf("sibling fields shared:\n%s", diff)
+	}
+}
+
+func TestNonFiniteFloats(t *testing.T) {
+	out := bytes.NewBuffer(make([]byte, 0, 1024))
+	logger := newLogger(out)
+	logger.WithDetail("detail", math.Inf(-1)).With(Float64("nan", math.NaN()), Float64("inf", math.Inf(1))).Info("scores")
+
+	var got Entry
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("decoding entry: %v\n%s", err, out)
+	}
+	want := Fields{"detail": "-Inf", "nan": "NaN", "inf": "+Inf"}
+	if diff := cmp.Diff(want, got.Details); diff != "" {
+		t.Errorf("unexpected details:\n%s", diff)
+	}
+}
+
+func TestLogVariants(t *testing.T) {
+	for _, log := range []func(){
+		func() { Log(SeverityNotice, defaultMessage, String("k", "v")) },
+		func() { std.Log(SeverityNotice, defaultMessage, String("k", "v")) },
+		func() { With(String("k", "v")).Log(SeverityNotice, defaultMessage) },
+		func() { std.With(String("k", "v")).Log(SeverityNotice, defaultMessage) },
+	} {
+		subTestSeverity(t, "NOTICE", func() {
+			log()
+			if !bytes.Contains(buf.Bytes(), []byte(`"details":{"k":"v"}`)) {
+				t.Errorf("field missing: %s", buf)
+			}
+		})
+	}
+}
+
+func TestTypedDetails(t *testing.T) {
+	d := &typedDetails{
+		details: Fields{"b": 1, "a": "from details", "z": true},
+		fields: []Field{
+			String("a", "first"), Label("b", "label"), String("s", "quote\" slash\\ \n\t\x01 <ü> \xff"),
+			Float64("f", 1), String("a", "last"),
+		},
+	}
+	got, err := json.Marshal(d)
+	if err != nil {
+		t.Fatalf("encoding details: %v", err)
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(got, &m); err != nil {
+		t.Fatalf("decoding details: %v\n%s", err, got)
+	}
+	want := map[string]interface{}{"a": "last", "b": 1.0, "z": true, "s": "quote\" slash\\ \n\t\x01 <ü> �", "f": 1.0}
+	if diff := cmp.Diff(want, m); diff != "" {
+		t.Errorf("unexpected details:\n%s", diff)
+	}
+	if !bytes.HasPrefix(got, []byte(`{"b":1,"z":true,"s":`)) {
+		t.Errorf("details not sorted before fields: %s", got)
+	}
+}
+
+func TestWithHook(t *testing.T) {
+	logger := newLogger(bytes.NewBuffer(make([]byte, 0, 1024)))
+	var got Fields
+	logger.AddHook(SeverityDebug, HookFunc(func(e *Entry) {
+		got = e.Details
+	}))
+	logger.WithDetail("d", 1).With(Int("f", 2)).Info("hooked")
+	if diff := cmp.Diff(Fields{"d": 1, "f": int64(2)}, got); diff != "" {
+		t.Errorf("unexpected hook details:\n%s", diff)
+	}
+}
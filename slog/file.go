@@ -0,0 +1,319 @@
+package slog
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// backupTimeFormat of rotated file names, which sorts in the order files were rotated.
+const backupTimeFormat = "20060102T150405.000"
+
+// FileConfig for a RotatingFile.
+type FileConfig struct {
+	// Path of the current log file. Backups are written alongside it.
+	Path string
+	// MaxSize in bytes and Interval after which the file is rotated, disabled if zero.
+	MaxSize  int64
+	Interval time.Duration
+	// MaxBackups kept, all are kept if zero.
+	MaxBackups int
+	// Compress backups with gzip.
+	Compress bool
+	// Perm of new files, defaults to 0644.
+	Perm os.FileMode
+	// ReopenOnSIGHUP closes and reopens the file on SIGHUP, for rotation by an external logrotate.
+	ReopenOnSIGHUP bool
+}
+
+// RotatingFile writer rotating by size and interval. Every Write is kept whole in a single file,
+// so entries written by a Sink are never split across a rotation.
+type RotatingFile struct {
+	cfg FileConfig
+	now func() time.Time
+
+	mu     sync.Mutex // guards the file
+	file   *os.File
+	size   int64
+	opened time.Time
+	closed bool
+
+	mill    sync.WaitGroup // compression and removal of backups
+	millMu  sync.Mutex     // ensures backups are handled one rotation at a time
+	signals chan os.Signal
+}
+
+// NewRotatingFile appending to the file at cfg.Path, creating it and its directory if needed.
+func NewRotatingFile(cfg FileConfig) (*RotatingFile, error) {
+	if cfg.Path == "" {
+		return nil, errors.New("rotating file requires a path")
+	}
+	if cfg.Perm == 0 {
+		cfg.Perm = 0644
+	}
+	f := &RotatingFile{cfg: cfg, now: time.Now}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	if cfg.ReopenOnSIGHUP {
+		f.signals = make(chan os.Signal, 1)
+		signal.Notify(f.signals, syscall.SIGHUP)
+		go f.reopenOnSignal(f.signals)
+	}
+	return f, nil
+}
+
+// NewFileSink writing JSON to a RotatingFile.
+func NewFileSink(cfg FileConfig) (*Sink, error) {
+	f, err := NewRotatingFile(cfg)
+	if err != nil {
+		return nil, err
+	}
+	s := NewSink(f)
+	s.owned = true
+	return s, nil
+}
+
+// open the file for appending, closing any current file once it is open. Must be called with mu held.
+func (f *RotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(f.cfg.Path), 0755); err != nil {
+		return fmt.Errorf("creating log directory: %w", err)
+	}
+	file, err := os.OpenFile(f.cfg.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, f.cfg.Perm)
+	if err != nil {
+		return fmt.Errorf("opening log file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("opening log file: %w", err)
+	}
+	old := f.file
+	f.file = file
+	f.size = info.Size()
+	f.opened = f.now()
+	if old != nil {
+		if err := old.Close(); err != nil {
+			return fmt.Errorf("closing log file: %w", err)
+		}
+	}
+	return nil
+}
+
+// Write p whole to the current file, rotating first if it would exceed the size or the interval has passed.
+// If an earlier rotation could not open a new file, opening it is tried again.
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return 0, os.ErrClosed
+	}
+	if f.file == nil {
+		if err := f.open(); err != nil {
+			return 0, err
+		}
+	}
+	if f.due(int64(len(p))) {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// due for rotation before writing n bytes. An empty file is never rotated. Must be called with mu held.
+func (f *RotatingFile) due(n int64) bool {
+	if f.size == 0 {
+		return false
+	}
+	if f.cfg.MaxSize > 0 && f.size+n > f.cfg.MaxSize {
+		return true
+	}
+	return f.cfg.Interval > 0 && f.now().Sub(f.opened) >= f.cfg.Interval
+}
+
+// Rotate the current file to a backup and start a new one.
+func (f *RotatingFile) Rotate() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return os.ErrClosed
+	}
+	return f.rotate()
+}
+
+// rotate the current file. If the file cannot be moved it is reopened to keep writing to it, if a new file
+// cannot be opened the next Write tries again. Must be called with mu held.
+func (f *RotatingFile) rotate() error {
+	if f.file != nil {
+		err := f.file.Close()
+		f.file = nil
+		if err != nil {
+			return fmt.Errorf("closing log file: %w", err)
+		}
+	}
+	ext := filepath.Ext(f.cfg.Path)
+	var backup string
+	// Rotations within the same millisecond are given the next free name so none are overwritten.
+	for t := f.now().UTC(); ; t = t.Add(time.Millisecond) {
+		backup = fmt.Sprint(strings.TrimSuffix(f.cfg.Path, ext), "-", t.Format(backupTimeFormat), ext)
+		if !exists(backup) && !exists(backup+".gz") {
+			break
+		}
+	}
+	if err := os.Rename(f.cfg.Path, backup); err != nil {
+		if oerr := f.open(); oerr != nil {
+			return fmt.Errorf("rotating log file: %v, %w", err, oerr)
+		}
+		return fmt.Errorf("rotating log file: %w", err)
+	}
+	if err := f.open(); err != nil {
+		return err
+	}
+	f.mill.Add(1)
+	go func() {
+		defer f.mill.Done()
+		if err := f.millBackups(backup); err != nil {
+			fmt.Fprintln(os.Stderr, "could not clean up log backups:", err)
+		}
+	}()
+	return nil
+}
+
+// millBackups compressing the newest backup and removing any beyond MaxBackups.
+func (f *RotatingFile) millBackups(backup string) error {
+	f.millMu.Lock()
+	defer f.millMu.Unlock()
+	if f.cfg.Compress {
+		// A backup may already have been removed by an earlier mill if rotations happened quickly.
+		if err := compressFile(backup, f.cfg.Perm); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if f.cfg.MaxBackups <= 0 {
+		return nil
+	}
+	backups, err := f.Backups()
+	if err != nil {
+		return err
+	}
+	for len(backups) > f.cfg.MaxBackups {
+		if err := os.Remove(backups[0]); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		backups = backups[1:]
+	}
+	return nil
+}
+
+// Backups of the file from oldest to newest.
+func (f *RotatingFile) Backups() ([]string, error) {
+	ext := filepath.Ext(f.cfg.Path)
+	prefix := strings.TrimSuffix(filepath.Base(f.cfg.Path), ext) + "-"
+	entries, err := os.ReadDir(filepath.Dir(f.cfg.Path))
+	if err != nil {
+		return nil, err
+	}
+	var backups []string
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		ts := strings.TrimSuffix(strings.TrimSuffix(name[len(prefix):], ".gz"), ext)
+		if _, err := time.Parse(backupTimeFormat, ts); err != nil {
+			continue
+		}
+		backups = append(backups, filepath.Join(filepath.Dir(f.cfg.Path), name))
+	}
+	sort.Strings(backups)
+	return backups, nil
+}
+
+// compressFile with gzip, replacing it with a .gz file.
+func compressFile(path string, perm os.FileMode) (err error) {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			out.Close()
+			os.Remove(path + ".gz")
+		}
+	}()
+	gz := gzip.NewWriter(out)
+	if _, err := io.Copy(gz, in); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+// exists reports whether a file exists at path.
+func exists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
+
+// Reopen the file at the configured path, after it was moved by an external tool such as logrotate.
+// The current file is kept if the new one cannot be opened.
+func (f *RotatingFile) Reopen() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return os.ErrClosed
+	}
+	return f.open()
+}
+
+// reopenOnSignal until signals is closed.
+func (f *RotatingFile) reopenOnSignal(signals chan os.Signal) {
+	for range signals {
+		if err := f.Reopen(); err != nil {
+			fmt.Fprintln(os.Stderr, "could not reopen log file:", err)
+		}
+	}
+}
+
+// Close the file, waiting for any backups to be compressed or removed.
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	if f.closed {
+		f.mu.Unlock()
+		return nil
+	}
+	f.closed = true
+	if f.signals != nil {
+		signal.Stop(f.signals)
+		close(f.signals)
+	}
+	var err error
+	if f.file != nil {
+		err = f.file.Close()
+	}
+	f.mu.Unlock()
+	f.mill.Wait()
+	return err
+}
@@ -0,0 +1,235 @@
+package slog
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// readLines of a log file, decompressing it if needed, failing if any line is not a whole entry.
+func readLines(t *testing.T, path string) []string {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("opening %s: %v", path, err)
+	}
+	defer f.Close()
+	var r = bufio.NewScanner(f)
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			t.Fatalf("decompressing %s: %v", path, err)
+		}
+		r = bufio.NewScanner(gz)
+	}
+	var lines []string
+	for r.Scan() {
+		var e Entry
+		if err := json.Unmarshal(r.Bytes(), &e); err != nil {
+			t.Fatalf("partial entry in %s: %v\n%s", path, err, r.Bytes())
+		}
+		lines = append(lines, e.Message)
+	}
+	return lines
+}
+
+func TestFileSinkSize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "app.log")
+	sink, err := NewFileSink(FileConfig{Path: path, MaxSize: 300, MaxBackups: 2, Compress: true})
+	if err != nil {
+		t.Fatalf("creating sink: %v", err)
+	}
+	logger := New(sink)
+	logger.SetIncludeSources(false)
+	e := logger.entry()
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				e.WithDetail("padding", strings.Repeat("x", 40)).Info("entry")
+			}
+		}()
+	}
+	wg.Wait()
+	if err := sink.Close(); err != nil {
+		t.Fatalf("closing sink: %v", err)
+	}
+
+	f := &RotatingFile{cfg: FileConfig{Path: path}}
+	backups, err := f.Backups()
+	if err != nil {
+		t.Fatalf("listing backups: %v", err)
+	}
+	if len(backups) != 2 {
+		t.Fatalf("unexpected backups kept\nwant: 2\ngot: %v", backups)
+	}
+	for _, b := range append(backups, path) {
+		info, err := os.Stat(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasSuffix(b, ".gz") && info.Size() > 300 {
+			t.Errorf("%s larger than max size: %d", b, info.Size())
+		}
+		if b != path && !strings.HasSuffix(b, ".gz") {
+			t.Errorf("backup not compressed: %s", b)
+		}
+		if len(readLines(t, b)) == 0 {
+			t.Errorf("empty file: %s", b)
+		}
+	}
+}
+
+func TestRotatingFileInterval(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	f, err := NewRotatingFile(FileConfig{Path: path, Interval: time.Hour})
+	if err != nil {
+		t.Fatalf("creating file: %v", err)
+	}
+	defer f.Close()
+	now := time.Date(2024, 1, 2, 3, 0, 0, 0, time.UTC)
+	f.now = func() time.Time { return now }
+	f.opened = now
+
+	write := func(msg string) {
+		t.Helper()
+		if _, err := f.Write([]byte(`{"message":"` + msg + `"}` + "\n")); err != nil {
+			t.Fatalf("writing: %v", err)
+		}
+	}
+	write("first")
+	now = now.Add(59 * time.Minute)
+	write("second")
+	now = now.Add(time.Minute)
+	write("third")
+	if err := f.Rotate(); err != nil {
+		t.Fatalf("rotating: %v", err)
+	}
+	write("fourth")
+	f.mill.Wait()
+
+	backups, err := f.Backups()
+	if err != nil {
+		t.Fatalf("listing backups: %v", err)
+	}
+	want := []string{
+		filepath.Join(filepath.Dir(path), "app-20240102T040000.000.log"),
+		filepath.Join(filepath.Dir(path), "app-20240102T040000.001.log"),
+	}
+	if strings.Join(backups, ",") != strings.Join(want, ",") {
+		t.Fatalf("unexpected backups\nwant: %v\ngot: %v", want, backups)
+	}
+	for file, lines := range map[string]string{want[0]: "first,second", want[1]: "third", path: "fourth"} {
+		if got := strings.Join(readLines(t, file), ","); got != lines {
+			t.Errorf("unexpected entries in %s\nwant: %s\ngot: %s", file, lines, got)
+		}
+	}
+}
+
+func TestRotatingFileReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	f, err := NewRotatingFile(FileConfig{Path: path, ReopenOnSIGHUP: true})
+	if err != nil {
+		t.Fatalf("creating file: %v", err)
+	}
+	defer f.Close()
+	if _, err := f.Write([]byte(`{"message":"before"}` + "\n")); err != nil {
+		t.Fatal(err)
+	}
+	// Rotate as logrotate would, then signal the process to reopen.
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	p, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Signal(syscall.SIGHUP); err != nil {
+		t.Fatalf("signalling: %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for !exists(path) {
+		if time.Now().After(deadline) {
+			t.Fatal("file not reopened")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if _, err := f.Write([]byte(`{"message":"after"}` + "\n")); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(readLines(t, path+".1"), ","); got != "before" {
+		t.Errorf("unexpected entries in moved file: %s", got)
+	}
+	if got := strings.Join(readLines(t, path), ","); got != "after" {
+		t.Errorf("unexpected entries in reopened file: %s", got)
+	}
+
+	if err := f.Close(); err != nil {
+		t.Errorf("closing: %v", err)
+	}
+	if _, err := f.Write([]byte("{}\n")); err == nil {
+		t.Error("expected error writing after close")
+	}
+	if _, err := NewRotatingFile(FileConfig{}); err == nil {
+		t.Error("expected error without path")
+	}
+}
+
+func TestRotatingFileFailures(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	f, err := NewRotatingFile(FileConfig{Path: path})
+	if err != nil {
+		t.Fatalf("creating file: %v", err)
+	}
+	defer f.Close()
+	write := func(msg string) {
+		t.Helper()
+		if _, err := f.Write([]byte(`{"message":"` + msg + `"}` + "\n")); err != nil {
+			t.Fatalf("writing %s: %v", msg, err)
+		}
+	}
+
+	// Reopening keeps the current file if the new one cannot be opened.
+	write("first")
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(path, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Reopen(); err == nil {
+		t.Error("expected error reopening over a directory")
+	}
+	write("second")
+	if got := strings.Join(readLines(t, path+".1"), ","); got != "first,second" {
+		t.Errorf("current file not kept: %s", got)
+	}
+
+	// A file that cannot be moved when rotating is reopened so writes continue.
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Reopen(); err != nil {
+		t.Fatalf("reopening: %v", err)
+	}
+	write("third")
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Rotate(); err == nil {
+		t.Error("expected error rotating a removed file")
+	}
+	write("fourth")
+	if got := strings.Join(readLines(t, path), ","); got != "fourth" {
+		t.Errorf("unexpected entries after failed rotation: %s", got)
+	}
+}
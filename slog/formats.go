@@ -0,0 +1,468 @@
+package slog
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// traceID of an entry without the Cloud Logging project prefix.
+func (e *Entry) traceID() string {
+	return e.Trace[strings.LastIndex(e.Trace, "/")+1:]
+}
+
+// location of the source as function:line, empty if sources are not included.
+func (s *SourceLocation) location() string {
+	if s == nil {
+		return ""
+	}
+	return s.Function + ":" + s.Line
+}
+
+// NewCloudWatchEncoder writing JSON for CloudWatch Logs with labels and details at the top level.
+// Details named in metrics with numeric values are published as CloudWatch metrics in the namespace
+// using the embedded metric format, with labels of the entry as dimensions.
+// Durations are published in milliseconds. A label or detail named _aws, reserved for the embedded metric format,
+// is written as _aws_detail instead.
+func NewCloudWatchEncoder(namespace string, metrics ...string) Encoder {
+	metric := make(map[string]bool, len(metrics))
+	for _, name := range metrics {
+		metric[name] = true
+	}
+	return EncoderFunc(func(buf *bytes.Buffer, e *Entry) error {
+		now := time.Now()
+		m := make(map[string]interface{}, len(e.Labels)+len(e.Details)+len(e.fields)+8)
+		for k, v := range e.Labels {
+			m[emfSafeKey(k)] = v
+		}
+		for k, v := range e.mergedDetails() {
+			k = emfSafeKey(k)
+			if metric[k] {
+				// Metric values are kept as is, so durations can be converted to milliseconds.
+				m[k] = v
+			} else {
+				m[k] = e.detail(v)
+			}
+		}
+		m["timestamp"] = now.UTC().Format(time.RFC3339Nano)
+		m["level"] = e.Severity.String()
+		m["message"] = e.Message
+		if e.Err != "" {
+			m["error"] = e.Err
+		}
+		if e.StackTrace != "" {
+			m["stack_trace"] = e.StackTrace
+		}
+		if loc := e.SourceLocation.location(); loc != "" {
+			m["location"] = loc
+		}
+		if id := e.traceID(); len(id) == 32 {
+			// X-Ray trace IDs split the same 96 bits of randomness after a 32 bit timestamp.
+			m["xray_trace_id"] = "1-" + id[:8] + "-" + id[8:]
+		}
+		if e.SpanID != "" {
+			m["span_id"] = e.SpanID
+		}
+		if e.Operation != nil {
+			m["operation"] = e.Operation
+		}
+		if e.HTTPRequest != nil {
+			m["http_request"] = e.HTTPRequest
+		}
+
+		var defs []emfMetric
+		for _, name := range metrics {
+			v, unit, ok := emfValue(m[name])
+			if !ok {
+				m[name] = e.detail(m[name])
+				continue
+			}
+			m[name] = v
+			defs = append(defs, emfMetric{Name: name, Unit: unit})
+		}
+		if len(defs) > 0 {
+			dims := make([]string, 0, len(e.Labels))
+			for k := range e.Labels {
+				dims = append(dims, emfSafeKey(k))
+			}
+			sort.Strings(dims)
+			if len(dims) > emfMaxDimensions {
+				dims = dims[:emfMaxDimensions]
+			}
+			m[emfKey] = emfMetadata{
+				Timestamp: now.UnixNano() / int64(time.Millisecond),
+				CloudWatchMetrics: []emfDirective{{
+					Namespace:  namespace,
+					Dimensions: [][]string{dims},
+					Metrics:    defs,
+				}},
+			}
+		}
+		return json.NewEncoder(buf).Encode(m)
+	})
+}
+
+// CloudWatchEncoder writes JSON for CloudWatch Logs without publishing metrics.
+var CloudWatchEncoder = NewCloudWatchEncoder("")
+
+// emfKey of the embedded metric format metadata.
+const emfKey = "_aws"
+
+// emfSafeKey renames a label or detail that would be read as embedded metric format metadata.
+func emfSafeKey(k string) string {
+	if k == emfKey {
+		return emfKey + "_detail"
+	}
+	return k
+}
+
+// emfMaxDimensions allowed in a dimension set by the embedded metric format.
+const emfMaxDimensions = 30
+
+// emfMetadata of the embedded metric format.
+// See https://docs.aws.amazon.com/AmazonCloudWatch/latest/monitoring/CloudWatch_Embedded_Metric_Format_Specification.html for reference.
+type emfMetadata struct {
+	Timestamp         int64          `json:"Timestamp"`
+	CloudWatchMetrics []emfDirective `json:"CloudWatchMetrics"`
+}
+
+type emfDirective struct {
+	Namespace  string      `json:"Namespace"`
+	Dimensions [][]string  `json:"Dimensions"`
+	Metrics    []emfMetric `json:"Metrics"`
+}
+
+type emfMetric struct {
+	Name string `json:"Name"`
+	Unit string `json:"Unit,omitempty"`
+}
+
+// emfValue of a detail as a metric value and unit, reporting false if it is not numeric.
+func emfValue(v interface{}) (float64, string, bool) {
+	switch v := v.(type) {
+	case time.Duration:
+		return float64(v) / float64(time.Millisecond), "Milliseconds", true
+	case int:
+		return float64(v), "None", true
+	case int32:
+		return float64(v), "None", true
+	case int64:
+		return float64(v), "None", true
+	case uint:
+		return float64(v), "None", true
+	case uint32:
+		return float64(v), "None", true
+	case uint64:
+		return float64(v), "None", true
+	case float32:
+		return float64(v), "None", true
+	case float64:
+		return v, "None", true
+	}
+	return 0, "", false
+}
+
+// datadogStatus of a severity, using the syslog names Datadog recognises.
+func datadogStatus(s Severity) string {
+	switch {
+	case s == SeverityDefault:
+		return "info"
+	case s == SeverityWarning:
+		return "warn"
+	}
+	return strings.ToLower(s.String())
+}
+
+// datadogID as the decimal form Datadog expects of the lower 64 bits of a hex trace or span ID.
+func datadogID(id string) string {
+	if len(id) > 16 {
+		id = id[len(id)-16:]
+	}
+	n, err := strconv.ParseUint(id, 16, 64)
+	if err != nil || n == 0 {
+		return ""
+	}
+	return strconv.FormatUint(n, 10)
+}
+
+// datadogEntry with Datadog reserved and standard attributes.
+// See https://docs.datadoghq.com/logs/log_configuration/attributes_naming_convention/ for reference.
+type datadogEntry struct {
+	Date         string                 `json:"date"`
+	Status       string                 `json:"status"`
+	Message      string                 `json:"message"`
+	Service      string                 `json:"service,omitempty"`
+	Tags         string                 `json:"ddtags,omitempty"`
+	TraceID      string                 `json:"dd.trace_id,omitempty"`
+	SpanID       string                 `json:"dd.span_id,omitempty"`
+	LoggerName   string                 `json:"logger.name,omitempty"`
+	LoggerMethod string                 `json:"logger.method_name,omitempty"`
+	ErrorMessage string                 `json:"error.message,omitempty"`
+	ErrorStack   string                 `json:"error.stack,omitempty"`
+	Method       string                 `json:"http.method,omitempty"`
+	URL          string                 `json:"http.url,omitempty"`
+	StatusCode   int                    `json:"http.status_code,omitempty"`
+	UserAgent    string                 `json:"http.useragent,omitempty"`
+	Referer      string                 `json:"http.referer,omitempty"`
+	ClientIP     string                 `json:"network.client.ip,omitempty"`
+	Operation    *Operation             `json:"operation,omitempty"`
+	Details      map[string]interface{} `json:"details,omitempty"`
+}
+
+// DatadogEncoder writes JSON using Datadog reserved attributes. The service comes from the LabelService label,
+// logger.name from the LabelLogName label and all labels are sent as tags.
+var DatadogEncoder Encoder = EncoderFunc(func(buf *bytes.Buffer, e *Entry) error {
+	d := datadogEntry{
+		Date:         time.Now().UTC().Format(time.RFC3339Nano),
+		Status:       datadogStatus(e.Severity),
+		Message:      e.Message,
+		Service:      e.Labels[LabelService],
+		LoggerName:   e.Labels[LabelLogName],
+		ErrorMessage: e.Err,
+		ErrorStack:   e.StackTrace,
+		Operation:    e.Operation,
+		Details:      e.normalizedDetails(),
+	}
+	if len(e.Labels) > 0 {
+		tags := make([]string, 0, len(e.Labels))
+		for k, v := range e.Labels {
+			tags = append(tags, k+":"+v)
+		}
+		sort.Strings(tags)
+		d.Tags = strings.Join(tags, ",")
+	}
+	if e.Trace != "" {
+		d.TraceID = datadogID(e.traceID())
+		d.SpanID = datadogID(e.SpanID)
+	}
+	if e.SourceLocation != nil {
+		d.LoggerMethod = e.SourceLocation.Function
+	}
+	if r := e.HTTPRequest; r != nil {
+		d.Method, d.URL, d.StatusCode = r.RequestMethod, r.RequestURL, r.Status
+		d.UserAgent, d.Referer, d.ClientIP = r.UserAgent, r.Referer, r.RemoteIP
+	}
+	return json.NewEncoder(buf).Encode(d)
+})
+
+// otlpSeverity number of a severity.
+// See https://opentelemetry.io/docs/specs/otel/logs/data-model/#field-severitynumber for reference.
+func otlpSeverity(s Severity) int {
+	switch {
+	case s >= SeverityEmergency:
+		return 21 // FATAL
+	case s >= SeverityAlert:
+		return 19 // ERROR3
+	case s >= SeverityCritical:
+		return 18 // ERROR2
+	case s >= SeverityError:
+		return 17 // ERROR
+	case s >= SeverityWarning:
+		return 13 // WARN
+	case s >= SeverityNotice:
+		return 10 // INFO2
+	case s >= SeverityInfo:
+		return 9 // INFO
+	case s >= SeverityDebug:
+		return 5 // DEBUG
+	}
+	return 0 // UNSPECIFIED
+}
+
+// otlpLogRecord in the OTLP JSON encoding.
+// See https://opentelemetry.io/docs/specs/otlp/#json-protobuf-encoding for reference.
+type otlpLogRecord struct {
+	TimeUnixNano         string          `json:"timeUnixNano"`
+	ObservedTimeUnixNano string          `json:"observedTimeUnixNano"`
+	SeverityNumber       int             `json:"severityNumber,omitempty"`
+	SeverityText         string          `json:"severityText,omitempty"`
+	Body                 otlpValue       `json:"body"`
+	Attributes           []otlpAttribute `json:"attributes,omitempty"`
+	TraceID              string          `json:"traceId,omitempty"`
+	SpanID               string          `json:"spanId,omitempty"`
+	Flags                uint32          `json:"flags,omitempty"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+// otlpValue is an AnyValue with exactly one field set.
+type otlpValue struct {
+	StringValue *string         `json:"stringValue,omitempty"`
+	BoolValue   *bool           `json:"boolValue,omitempty"`
+	IntValue    *string         `json:"intValue,omitempty"`
+	DoubleValue *float64        `json:"doubleValue,omitempty"`
+	ArrayValue  *otlpArray      `json:"arrayValue,omitempty"`
+	KvlistValue *otlpArrayOfKVs `json:"kvlistValue,omitempty"`
+}
+
+type otlpArray struct {
+	Values []otlpValue `json:"values"`
+}
+
+type otlpArrayOfKVs struct {
+	Values []otlpAttribute `json:"values"`
+}
+
+func otlpString(s string) otlpValue {
+	return otlpValue{StringValue: &s}
+}
+
+func otlpInt(n int64) otlpValue {
+	s := strconv.FormatInt(n, 10)
+	return otlpValue{IntValue: &s}
+}
+
+// otlpUint as an intValue, clamped to the largest int64 as OTLP integers are signed.
+func otlpUint(n uint64) otlpValue {
+	if n > math.MaxInt64 {
+		n = math.MaxInt64
+	}
+	return otlpInt(int64(n))
+}
+
+// newOTLPValue from a detail, values without a direct equivalent are converted through JSON.
+func newOTLPValue(v interface{}) otlpValue {
+	switch v := v.(type) {
+	case nil:
+		return otlpValue{}
+	case string:
+		return otlpString(v)
+	case bool:
+		return otlpValue{BoolValue: &v}
+	case int:
+		return otlpInt(int64(v))
+	case int8:
+		return otlpInt(int64(v))
+	case int16:
+		return otlpInt(int64(v))
+	case int32:
+		return otlpInt(int64(v))
+	case int64:
+		return otlpInt(v)
+	case uint:
+		return otlpUint(uint64(v))
+	case uint8:
+		return otlpInt(int64(v))
+	case uint16:
+		return otlpInt(int64(v))
+	case uint32:
+		return otlpInt(int64(v))
+	case uint64:
+		return otlpUint(v)
+	case time.Duration:
+		return otlpString(v.String())
+	case float32:
+		f := float64(v)
+		return otlpValue{DoubleValue: &f}
+	case float64:
+		return otlpValue{DoubleValue: &v}
+	case time.Time:
+		return otlpString(v.Format(time.RFC3339Nano))
+	case []string:
+		a := &otlpArray{Values: make([]otlpValue, len(v))}
+		for i, s := range v {
+			a.Values[i] = otlpString(s)
+		}
+		return otlpValue{ArrayValue: a}
+	case []interface{}:
+		a := &otlpArray{Values: make([]otlpValue, len(v))}
+		for i, x := range v {
+			a.Values[i] = newOTLPValue(x)
+		}
+		return otlpValue{ArrayValue: a}
+	case map[string]interface{}:
+		return otlpValue{KvlistValue: &otlpArrayOfKVs{Values: otlpAttributes(v)}}
+	case Fields:
+		return otlpValue{KvlistValue: &otlpArrayOfKVs{Values: otlpAttributes(v)}}
+	case error:
+		return otlpString(v.Error())
+	}
+	// Anything else, such as structs, is converted to its generic JSON form first.
+	b, err := json.Marshal(v)
+	if err != nil {
+		return otlpString(err.Error())
+	}
+	var generic interface{}
+	if err := json.Unmarshal(b, &generic); err != nil {
+		return otlpString(string(b))
+	}
+	return newOTLPValue(generic)
+}
+
+// otlpAttributes from a map, sorted by key.
+func otlpAttributes(m map[string]interface{}) []otlpAttribute {
+	attrs := make([]otlpAttribute, 0, len(m))
+	for k, v := range m {
+		attrs = append(attrs, otlpAttribute{Key: k, Value: newOTLPValue(v)})
+	}
+	sort.Slice(attrs, func(i, j int) bool { return attrs[i].Key < attrs[j].Key })
+	return attrs
+}
+
+// newOTLPLogRecord from an entry, attribute names follow the OpenTelemetry semantic conventions.
+func newOTLPLogRecord(e *Entry, now time.Time) *otlpLogRecord {
+	ts := strconv.FormatInt(now.UnixNano(), 10)
+	r := &otlpLogRecord{
+		TimeUnixNano:         ts,
+		ObservedTimeUnixNano: ts,
+		SeverityNumber:       otlpSeverity(e.Severity),
+		Body:                 otlpString(e.Message),
+	}
+	if e.Severity != SeverityDefault {
+		r.SeverityText = e.Severity.String()
+	}
+	if e.Trace != "" {
+		r.TraceID = e.traceID()
+		r.SpanID = e.SpanID
+		if e.TraceSampled {
+			r.Flags = 1
+		}
+	}
+	attrs := make(map[string]interface{}, len(e.Labels)+len(e.Details)+len(e.fields)+8)
+	for k, v := range e.Labels {
+		attrs[k] = v
+	}
+	for k, v := range e.mergedDetails() {
+		attrs[k] = e.detail(v)
+	}
+	if e.Err != "" {
+		attrs["exception.message"] = e.Err
+	}
+	if e.StackTrace != "" {
+		attrs["exception.stacktrace"] = e.StackTrace
+	}
+	if s := e.SourceLocation; s != nil {
+		attrs["code.filepath"] = s.File
+		attrs["code.function"] = s.Function
+		if line, err := strconv.Atoi(s.Line); err == nil {
+			attrs["code.lineno"] = line
+		}
+	}
+	if h := e.HTTPRequest; h != nil {
+		attrs["http.request.method"] = h.RequestMethod
+		attrs["url.full"] = h.RequestURL
+		attrs["user_agent.original"] = h.UserAgent
+		attrs["client.address"] = h.RemoteIP
+		if h.Status != 0 {
+			attrs["http.response.status_code"] = h.Status
+		}
+	}
+	if o := e.Operation; o != nil {
+		attrs["operation.id"] = o.ID
+		attrs["operation.producer"] = o.Producer
+	}
+	r.Attributes = otlpAttributes(attrs)
+	return r
+}
+
+// OTLPEncoder writes each entry as an OTLP JSON log record.
+var OTLPEncoder Encoder = EncoderFunc(func(buf *bytes.Buffer, e *Entry) error {
+	return json.NewEncoder(buf).Encode(newOTLPLogRecord(e, time.Now()))
+})
@@ -0,0 +1,192 @@
+package slog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// formatEntry logged with an encoder and decoded into a generic map.
+func formatEntry(t *testing.T, enc Encoder) map[string]interface{} {
+	t.Helper()
+	out := bytes.NewBuffer(make([]byte, 0, 4096))
+	sink := NewSink(out)
+	sink.SetEncoder(enc)
+	logger := New(sink)
+	logger.SetIncludeSources(true)
+	e := logger.entry()
+	e.Trace = "projects/p/traces/4bf92f3577b34da6a3ce929d0e0e4736"
+	e.SpanID = "00f067aa0ba902b7"
+	e.TraceSampled = true
+	e.WithLabels(Fields{LabelService: "records", LabelLogName: "app"}).
+		WithDetail("patient", "abc").
+		With(Duration("took", 1500*time.Millisecond), Int("count", 3), Err(errors.New("failed"))).
+		Error("lookup failed")
+
+	var m map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &m); err != nil {
+		t.Fatalf("decoding entry: %v\n%s", err, out)
+	}
+	return m
+}
+
+// pick keys from a map, so tests can ignore values that change between runs.
+func pick(m map[string]interface{}, keys ...string) map[string]interface{} {
+	got := make(map[string]interface{}, len(keys))
+	for _, k := range keys {
+		if v, ok := m[k]; ok {
+			got[k] = v
+		}
+	}
+	return got
+}
+
+func TestCloudWatchEncoder(t *testing.T) {
+	m := formatEntry(t, NewCloudWatchEncoder("tau", "took", "count", "patient", "missing"))
+	want := map[string]interface{}{
+		"level":         "ERROR",
+		"message":       "lookup failed",
+		"error":         "failed",
+		"xray_trace_id": "1-4bf92f35-77b34da6a3ce929d0e0e4736",
+		"span_id":       "00f067aa0ba902b7",
+		"service":       "records",
+		"patient":       "abc",
+		"took":          1500.0,
+		"count":         3.0,
+	}
+	if diff := cmp.Diff(want, pick(m, "level", "message", "error", "xray_trace_id", "span_id", "service", "patient", "took", "count")); diff != "" {
+		t.Errorf("unexpected entry:\n%s", diff)
+	}
+	if _, err := time.Parse(time.RFC3339Nano, m["timestamp"].(string)); err != nil {
+		t.Errorf("invalid timestamp: %v", err)
+	}
+	aws, _ := m["_aws"].(map[string]interface{})
+	wantAWS := map[string]interface{}{
+		"CloudWatchMetrics": []interface{}{map[string]interface{}{
+			"Namespace":  "tau",
+			"Dimensions": []interface{}{[]interface{}{"log_name", "service"}},
+			"Metrics": []interface{}{
+				map[string]interface{}{"Name": "took", "Unit": "Milliseconds"},
+				map[string]interface{}{"Name": "count", "Unit": "None"},
+			},
+		}},
+	}
+	if diff := cmp.Diff(wantAWS, pick(aws, "CloudWatchMetrics")); diff != "" {
+		t.Errorf("unexpected metrics:\n%s", diff)
+	}
+	if _, ok := aws["Timestamp"].(float64); !ok {
+		t.Errorf("metrics missing timestamp: %v", aws)
+	}
+
+	if m := formatEntry(t, CloudWatchEncoder); m["_aws"] != nil {
+		t.Errorf("metrics included without names: %v", m["_aws"])
+	}
+
+	out := bytes.NewBuffer(make([]byte, 0, 1024))
+	sink := NewSink(out)
+	sink.SetEncoder(NewCloudWatchEncoder("tau", "count"))
+	New(sink).WithDetails(Fields{"_aws": "spoofed", "count": 1}).Info("reserved")
+	var reserved map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &reserved); err != nil {
+		t.Fatalf("decoding entry: %v", err)
+	}
+	if _, ok := reserved["_aws"].(map[string]interface{}); !ok || reserved["_aws_detail"] != "spoofed" {
+		t.Errorf("metadata overwritten by detail: %v", reserved)
+	}
+}
+
+func TestDatadogEncoder(t *testing.T) {
+	m := formatEntry(t, DatadogEncoder)
+	want := map[string]interface{}{
+		"status":             "error",
+		"message":            "lookup failed",
+		"service":            "records",
+		"ddtags":             "log_name:app,service:records",
+		"dd.trace_id":        "11803532876627986230",
+		"dd.span_id":         "67667974448284343",
+		"logger.name":        "app",
+		"logger.method_name": "github.com/ParticleHealth/tau/slog.formatEntry",
+		"error.message":      "failed",
+		"details":            map[string]interface{}{"patient": "abc", "took": "1.5s", "count": 3.0},
+	}
+	if diff := cmp.Diff(want, pick(m, "status", "message", "service", "ddtags", "dd.trace_id", "dd.span_id", "logger.name", "logger.method_name", "error.message", "details")); diff != "" {
+		t.Errorf("unexpected entry:\n%s", diff)
+	}
+	if m["error.stack"] == nil || m["date"] == nil {
+		t.Errorf("missing stack or date: %v", m)
+	}
+	for s, want := range map[Severity]string{SeverityDefault: "info", SeverityWarning: "warn", SeverityNotice: "notice", SeverityEmergency: "emergency"} {
+		if got := datadogStatus(s); got != want {
+			t.Errorf("unexpected status for %s\nwant: %s\ngot: %s", s, want, got)
+		}
+	}
+}
+
+func TestOTLPEncoder(t *testing.T) {
+	m := formatEntry(t, OTLPEncoder)
+	want := map[string]interface{}{
+		"severityNumber": 17.0,
+		"severityText":   "ERROR",
+		"body":           map[string]interface{}{"stringValue": "lookup failed"},
+		"traceId":        "4bf92f3577b34da6a3ce929d0e0e4736",
+		"spanId":         "00f067aa0ba902b7",
+		"flags":          1.0,
+	}
+	if diff := cmp.Diff(want, pick(m, "severityNumber", "severityText", "body", "traceId", "spanId", "flags")); diff != "" {
+		t.Errorf("unexpected record:\n%s", diff)
+	}
+	attrs := make(map[string]interface{})
+	for _, a := range m["attributes"].([]interface{}) {
+		a := a.(map[string]interface{})
+		attrs[a["key"].(string)] = a["value"]
+	}
+	wantAttrs := map[string]interface{}{
+		"service":           map[string]interface{}{"stringValue": "records"},
+		"patient":           map[string]interface{}{"stringValue": "abc"},
+		"took":              map[string]interface{}{"stringValue": "1.5s"},
+		"count":             map[string]interface{}{"intValue": "3"},
+		"exception.message": map[string]interface{}{"stringValue": "failed"},
+		"code.function":     map[string]interface{}{"stringValue": "github.com/ParticleHealth/tau/slog.formatEntry"},
+	}
+	if diff := cmp.Diff(wantAttrs, pick(attrs, "service", "patient", "took", "count", "exception.message", "code.function")); diff != "" {
+		t.Errorf("unexpected attributes:\n%s", diff)
+	}
+	if _, ok := attrs["code.lineno"].(map[string]interface{})["intValue"]; !ok {
+		t.Errorf("line not an int: %v", attrs["code.lineno"])
+	}
+
+	for s, want := range map[Severity]int{SeverityDefault: 0, SeverityDebug: 5, SeverityNotice: 10, SeverityWarning: 13, SeverityAlert: 19, SeverityEmergency: 21} {
+		if got := otlpSeverity(s); got != want {
+			t.Errorf("unexpected severity number for %s\nwant: %d\ngot: %d", s, want, got)
+		}
+	}
+}
+
+func TestOTLPValue(t *testing.T) {
+	v := newOTLPValue(map[string]interface{}{"list": []interface{}{"a", 1.5, true}, "obj": struct {
+		N int `json:"n"`
+	}{2}})
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"kvlistValue":{"values":[{"key":"list","value":{"arrayValue":{"values":[{"stringValue":"a"},{"doubleValue":1.5},{"boolValue":true}]}}},{"key":"obj","value":{"kvlistValue":{"values":[{"key":"n","value":{"doubleValue":2}}]}}}]}}`
+	if string(b) != want {
+		t.Errorf("unexpected value\nwant: %s\ngot: %s", want, b)
+	}
+
+	for _, v := range []interface{}{int8(-3), int16(-3), uint(3), uint8(3), uint16(3), uint64(3)} {
+		if got := newOTLPValue(v); got.IntValue == nil {
+			t.Errorf("%T not written as an integer: %+v", v, got)
+		}
+	}
+	if got := newOTLPValue(uint64(math.MaxUint64)); got.IntValue == nil || *got.IntValue != strconv.FormatInt(math.MaxInt64, 10) {
+		t.Errorf("large uint64 not clamped: %+v", got)
+	}
+}
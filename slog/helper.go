@@ -0,0 +1,136 @@
+package slog
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// helperDepth of frames searched for a caller that is not a helper.
+const helperDepth = 32
+
+var (
+	helperMu   sync.RWMutex // guards helpers and helperPkgs
+	helpers    = make(map[string]bool)
+	helperPkgs []string
+	helperGen  int32 // incremented whenever helpers change so cached sources are checked again, read atomically
+)
+
+// Helper marks the calling function as a logging helper, in the manner of testing.T.Helper.
+// Source locations and stacks skip past helpers to the code that called them.
+func Helper() {
+	var pcs [1]uintptr
+	if runtime.Callers(2, pcs[:]) == 0 {
+		return
+	}
+	frame, _ := runtime.CallersFrames(pcs[:]).Next()
+	helperMu.RLock()
+	marked := helpers[frame.Function]
+	helperMu.RUnlock()
+	if marked {
+		return
+	}
+	helperMu.Lock()
+	defer helperMu.Unlock()
+	helpers[frame.Function] = true
+	atomic.AddInt32(&helperGen, 1)
+}
+
+// HelperPackage marks every function in the package with the given import path as a logging helper.
+func HelperPackage(path string) {
+	helperMu.Lock()
+	defer helperMu.Unlock()
+	helperPkgs = append(helperPkgs, path+".")
+	atomic.AddInt32(&helperGen, 1)
+}
+
+// isHelper reports whether the function, or the function enclosing a closure, was marked with Helper
+// or is in a helper package.
+func isHelper(function string) bool {
+	if atomic.LoadInt32(&helperGen) == 0 {
+		return false
+	}
+	helperMu.RLock()
+	defer helperMu.RUnlock()
+	if helpers[function] || helpers[enclosingFunc(function)] {
+		return true
+	}
+	for _, pkg := range helperPkgs {
+		if strings.HasPrefix(function, pkg) {
+			return true
+		}
+	}
+	return false
+}
+
+// enclosingFunc of a closure, such as pkg.Helper for pkg.Helper.func1 or pkg.Helper.func1.2.
+// Other functions are returned as is.
+func enclosingFunc(function string) string {
+	slash := strings.LastIndexByte(function, '/')
+	for {
+		i := strings.LastIndexByte(function, '.')
+		if i <= slash || strings.LastIndexByte(function[:i], '.') <= slash || !isClosure(function[i+1:]) {
+			return function
+		}
+		function = function[:i]
+	}
+}
+
+// isClosure reports whether a function name element is generated for a closure, such as func1 or 2.
+func isClosure(name string) bool {
+	for _, prefix := range []string{"func", "gowrap", "deferwrap"} {
+		if strings.HasPrefix(name, prefix) {
+			name = name[len(prefix):]
+			break
+		}
+	}
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// callerSource of the first caller that is not a helper, skipping depth frames as for runtime.Caller.
+func callerSource(depth int) *SourceLocation {
+	var pcs [helperDepth]uintptr
+	n := runtime.Callers(depth+2, pcs[:])
+	if n == 0 {
+		return nil
+	}
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if !more || !isHelper(frame.Function) {
+			return &SourceLocation{
+				File:     frame.File,
+				Line:     fmt.Sprint(frame.Line),
+				Function: frame.Function,
+			}
+		}
+	}
+}
+
+// WithCallerSkip of n additional frames for the source location and stack. Will create a child entry.
+// Use it from logging helpers that cannot call Helper, skip counts are added to any already set.
+func (e *Entry) WithCallerSkip(n int) *Entry {
+	c := e.clone()
+	c.skip += n
+	return c
+}
+
+// WithCallerSkip of n additional frames for the source location and stack. Will create a child entry.
+func WithCallerSkip(n int) *Entry {
+	return std.entry().WithCallerSkip(n)
+}
+
+// WithCallerSkip of n additional frames for the source location and stack. Will create a child entry.
+func (l *Logger) WithCallerSkip(n int) *Entry {
+	return l.entry().WithCallerSkip(n)
+}
@@ -0,0 +1,87 @@
+package slog
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func logFHIRError(e *Entry, msg string) {
+	Helper()
+	e.Error(msg)
+}
+
+func logDeferred(e *Entry, msg string) {
+	Helper()
+	func() { e.Error(msg) }()
+}
+
+func logSkipped(e *Entry, msg string) {
+	e.WithCallerSkip(1).Error(msg)
+}
+
+func TestHelpers(t *testing.T) {
+	tests := []struct {
+		name string
+		log  func(e *Entry)
+	}{
+		{"helper", func(e *Entry) { logFHIRError(e, "failed") }},
+		{"helper closure", func(e *Entry) { logDeferred(e, "failed") }},
+		{"caller skip", func(e *Entry) { logSkipped(e, "failed") }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := bytes.NewBuffer(make([]byte, 0, 4096))
+			logger := newLogger(out)
+			logger.SetIncludeSources(true)
+			tt.log(logger.entry())
+
+			var e Entry
+			if err := json.Unmarshal(out.Bytes(), &e); err != nil {
+				t.Fatalf("decoding entry: %v\n%s", err, out)
+			}
+			const want = "github.com/ParticleHealth/tau/slog.TestHelpers.func"
+			if e.SourceLocation == nil || !strings.HasPrefix(e.SourceLocation.Function, want) {
+				t.Errorf("unexpected source\nwant: %s\ngot: %+v", want, e.SourceLocation)
+			}
+			if !strings.HasSuffix(e.SourceLocation.File, "helper_test.go") {
+				t.Errorf("unexpected file: %s", e.SourceLocation.File)
+			}
+			subTestStack(t, out.String(), "failed", want)
+		})
+	}
+}
+
+func TestHelperPackage(t *testing.T) {
+	HelperPackage("example.com/fhirlog")
+	for fn, want := range map[string]bool{
+		"example.com/fhirlog.Error":          true,
+		"example.com/fhirlog.(*Logger).Warn": true,
+		"example.com/fhirlogger.Error":       false,
+		"example.com/other.Error":            false,
+	} {
+		if got := isHelper(fn); got != want {
+			t.Errorf("unexpected helper status for %s\nwant: %v\ngot: %v", fn, want, got)
+		}
+	}
+}
+
+func TestEnclosingFunc(t *testing.T) {
+	for fn, want := range map[string]string{
+		"example.com/fhirlog.Error":                 "example.com/fhirlog.Error",
+		"example.com/fhirlog.Error.func1":           "example.com/fhirlog.Error",
+		"example.com/fhirlog.Error.func1.2":         "example.com/fhirlog.Error",
+		"example.com/fhirlog.(*Logger).Warn.func3":  "example.com/fhirlog.(*Logger).Warn",
+		"example.com/fhirlog.Error.gowrap1":         "example.com/fhirlog.Error",
+		"example.com/fhirlog.func1":                 "example.com/fhirlog.func1",
+		"example.com/v2.func1":                      "example.com/v2.func1",
+		"main.main.func1":                           "main.main",
+		"example.com/fhirlog.function":              "example.com/fhirlog.function",
+		"example.com/fhirlog.(*Logger).funcs.func1": "example.com/fhirlog.(*Logger).funcs",
+	} {
+		if got := enclosingFunc(fn); got != want {
+			t.Errorf("unexpected enclosing function for %s\nwant: %s\ngot: %s", fn, want, got)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package slog
+
+import (
+	"fmt"
+	"os"
+	"sync/atomic"
+)
+
+// hookQueueSize of entries waiting for an asynchronous hook before further entries are dropped.
+const hookQueueSize = 256
+
+// Hook for side effects, such as metrics or paging, when specific entries are written.
+type Hook interface {
+	// Fire with the finalized Entry. The Entry must not be modified or retained.
+	Fire(e *Entry)
+}
+
+// HookFunc allows a function to be used as a Hook.
+type HookFunc func(e *Entry)
+
+// Fire by calling f.
+func (f HookFunc) Fire(e *Entry) {
+	f(e)
+}
+
+// hook registered with a Logger.
+type hook struct {
+	h       Hook
+	min     Severity
+	queue   chan *Entry
+	dropped uint64
+}
+
+// fire the hook with an entry if its severity is high enough.
+func (h *hook) fire(e *Entry) {
+	if e.Severity < h.min {
+		return
+	}
+	if h.queue == nil {
+		h.call(e)
+		return
+	}
+	select {
+	case h.queue <- e:
+	default:
+		atomic.AddUint64(&h.dropped, 1)
+	}
+}
+
+// call the hook, isolating the logger from any panics.
+func (h *hook) call(e *Entry) {
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Fprintln(os.Stderr, "log hook panicked:", r)
+		}
+	}()
+	h.h.Fire(e)
+}
+
+// run an asynchronous hook for each queued entry.
+func (h *hook) run() {
+	for e := range h.queue {
+		h.call(e)
+	}
+}
+
+// AddHook fired for every entry written at or above min, such as SeverityAlert.
+// The hook is called after the entry is written, once the logger is no longer locked,
+// so it may log. Panics in the hook are recovered and reported to stderr.
+func (l *Logger) AddHook(min Severity, h Hook) {
+	l.addHook(&hook{h: h, min: min})
+}
+
+// AddHook fired for every entry written by the package-level logger at or above min, such as SeverityAlert.
+// The hook is called after the entry is written, once the logger is no longer locked,
+// so it may log. Panics in the hook are recovered and reported to stderr.
+func AddHook(min Severity, h Hook) {
+	std.AddHook(min, h)
+}
+
+// AddAsyncHook fired from a separate goroutine for every entry written at or above min, such as SeverityAlert.
+// Entries are dropped if the hook falls too far behind.
+func (l *Logger) AddAsyncHook(min Severity, h Hook) {
+	ah := &hook{h: h, min: min, queue: make(chan *Entry, hookQueueSize)}
+	go ah.run()
+	l.addHook(ah)
+}
+
+// AddAsyncHook fired from a separate goroutine for every entry written by the package-level logger
+// at or above min, such as SeverityAlert. Entries are dropped if the hook falls too far behind.
+func AddAsyncHook(min Severity, h Hook) {
+	std.AddAsyncHook(min, h)
+}
+
+// addHook to the hooks fired by the logger.
+func (l *Logger) addHook(h *hook) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.hooks = append(l.hooks[:len(l.hooks):len(l.hooks)], h)
+}
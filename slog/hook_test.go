@@ -0,0 +1,78 @@
+package slog
+
+import (
+	"bytes"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHooks(t *testing.T) {
+	out := bytes.NewBuffer(make([]byte, 0, 1024))
+	logger := newLogger(out)
+	var fired int32
+	logger.AddHook(SeverityAlert, HookFunc(func(e *Entry) {
+		atomic.AddInt32(&fired, 1)
+		if e.Message != "paging" {
+			t.Errorf("hook got unfinalized entry: %+v", e)
+		}
+	}))
+	logger.Error("ignored")
+	logger.Alert("paging")
+	logger.Emergency("paging")
+	if got := atomic.LoadInt32(&fired); got != 2 {
+		t.Errorf("hook not filtered by severity\nwant: 2\ngot: %d", got)
+	}
+}
+
+func TestHookCanLog(t *testing.T) {
+	out := bytes.NewBuffer(make([]byte, 0, 1024))
+	logger := newLogger(out)
+	logger.AddHook(SeverityAlert, HookFunc(func(e *Entry) {
+		logger.Info("notified pager")
+	}))
+	done := make(chan struct{})
+	go func() {
+		logger.Alert("paging")
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("hook deadlocked logger")
+	}
+	if !strings.Contains(out.String(), "notified pager") {
+		t.Errorf("hook did not log: %s", out)
+	}
+}
+
+func TestHookPanicIsolated(t *testing.T) {
+	out := bytes.NewBuffer(make([]byte, 0, 1024))
+	logger := newLogger(out)
+	var fired int32
+	logger.AddHook(SeverityDebug, HookFunc(func(e *Entry) { panic("hook failure") }))
+	logger.AddHook(SeverityDebug, HookFunc(func(e *Entry) { atomic.AddInt32(&fired, 1) }))
+	logger.Info("hello")
+	if atomic.LoadInt32(&fired) != 1 {
+		t.Error("panicking hook prevented other hooks")
+	}
+	if !strings.Contains(out.String(), "hello") {
+		t.Errorf("panicking hook prevented write: %s", out)
+	}
+}
+
+func TestAsyncHook(t *testing.T) {
+	logger := newLogger(bytes.NewBuffer(make([]byte, 0, 1024)))
+	got := make(chan *Entry, 1)
+	logger.AddAsyncHook(SeverityEmergency, HookFunc(func(e *Entry) { got <- e }))
+	logger.WithLabels(Fields{"hello": "world"}).Emergency("async")
+	select {
+	case e := <-got:
+		if e.Message != "async" || e.Labels["hello"] != "world" {
+			t.Errorf("unexpected entry: %+v", e)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("async hook not fired")
+	}
+}
@@ -0,0 +1,190 @@
+package slog
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"runtime"
+	"strings"
+)
+
+// panicDepth of the stack captured for a recovered panic, including runtime frames that are dropped.
+const panicDepth = 64
+
+// HTTPRequest details for an entry.
+// See https://cloud.google.com/logging/docs/reference/v2/rest/v2/LogEntry#HttpRequest for reference.
+type HTTPRequest struct {
+	RequestMethod string `json:"requestMethod,omitempty"`
+	RequestURL    string `json:"requestUrl,omitempty"`
+	Status        int    `json:"status,omitempty"`
+	UserAgent     string `json:"userAgent,omitempty"`
+	RemoteIP      string `json:"remoteIp,omitempty"`
+	Referer       string `json:"referer,omitempty"`
+	Protocol      string `json:"protocol,omitempty"`
+}
+
+// newHTTPRequest from an incoming request, taking the remote IP from X-Forwarded-For only when sent by trusted proxies.
+func newHTTPRequest(r *http.Request, proxies []*net.IPNet) *HTTPRequest {
+	return &HTTPRequest{
+		RequestMethod: r.Method,
+		RequestURL:    r.URL.String(),
+		UserAgent:     r.UserAgent(),
+		RemoteIP:      remoteIP(r, proxies),
+		Referer:       r.Referer(),
+		Protocol:      r.Proto,
+	}
+}
+
+// remoteIP of a request. When the connection is from a trusted proxy, X-Forwarded-For is read from the right
+// and the first address that is not a trusted proxy is used, as any addresses to its left can be forged.
+func remoteIP(r *http.Request, proxies []*net.IPNet) string {
+	remote := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(remote); err == nil {
+		remote = host
+	}
+	if !trusted(remote, proxies) {
+		return remote
+	}
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if hop == "" {
+			continue
+		}
+		remote = hop
+		if !trusted(hop, proxies) {
+			break
+		}
+	}
+	return remote
+}
+
+// trusted reports whether ip is within one of the trusted proxies.
+func trusted(ip string, proxies []*net.IPNet) bool {
+	if len(proxies) == 0 {
+		return false
+	}
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, p := range proxies {
+		if p.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetTrustedProxies whose X-Forwarded-For header is used for the remote IP of requests, as IP addresses
+// or CIDR ranges such as 10.0.0.0/8. The rightmost forwarded address not from a trusted proxy is used.
+// Without trusted proxies the header is ignored, as clients can set it to anything.
+func (l *Logger) SetTrustedProxies(proxies ...string) error {
+	nets := make([]*net.IPNet, 0, len(proxies))
+	for _, p := range proxies {
+		if !strings.Contains(p, "/") {
+			ip := net.ParseIP(p)
+			if ip == nil {
+				return fmt.Errorf("invalid trusted proxy %q", p)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, n, err := net.ParseCIDR(p)
+		if err != nil {
+			return fmt.Errorf("invalid trusted proxy %q: %w", p, err)
+		}
+		nets = append(nets, n)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.proxies = nets
+	return nil
+}
+
+// SetTrustedProxies whose X-Forwarded-For header is used for the remote IP of requests for the package-level logger.
+func SetTrustedProxies(proxies ...string) error {
+	return std.SetTrustedProxies(proxies...)
+}
+
+// trustedProxies set for the logger.
+func (l *Logger) trustedProxies() []*net.IPNet {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.proxies
+}
+
+// WithHTTPRequest details included for a given request. Will create a child entry.
+func (e *Entry) WithHTTPRequest(r *http.Request) *Entry {
+	c := e.clone()
+	c.HTTPRequest = newHTTPRequest(r, e.logger.trustedProxies())
+	return c
+}
+
+// WithHTTPRequest details included for a given request. Will create a child entry.
+func WithHTTPRequest(r *http.Request) *Entry {
+	return std.entry().WithHTTPRequest(r)
+}
+
+// WithHTTPRequest details included for a given request. Will create a child entry.
+func (l *Logger) WithHTTPRequest(r *http.Request) *Entry {
+	return l.entry().WithHTTPRequest(r)
+}
+
+// panicStack of the goroutine that panicked, starting at the frame that called panic.
+// Must be called from the deferred function that recovered.
+func panicStack() stack {
+	var pcs [panicDepth]uintptr
+	n := runtime.Callers(3, pcs[:])
+	start := 0
+	for i := 0; i < n; i++ {
+		fn := runtime.FuncForPC(pcs[i] - 1)
+		if fn != nil && fn.Name() == "runtime.gopanic" {
+			start = i + 1
+		}
+	}
+	// Skip runtime frames raising the panic, such as for a nil pointer dereference.
+	for start < n {
+		fn := runtime.FuncForPC(pcs[start] - 1)
+		if fn == nil || !strings.HasPrefix(fn.Name(), "runtime.") {
+			break
+		}
+		start++
+	}
+	return pcs[start:n:n]
+}
+
+// RecoverHandler recovers panics from next, logging them at Critical with the Entry from the request
+// context and responding with a 500. The stack of the panicking goroutine is included for Error Reporting,
+// with the source location where the panic was raised.
+// http.ErrAbortHandler is passed on so the server can abort the response as usual.
+func RecoverHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+			if v == http.ErrAbortHandler {
+				panic(v)
+			}
+			e := FromContext(r.Context()).clone()
+			e.stack = panicStack()
+			e.stackSource = true
+			if err, ok := v.(error); ok {
+				e.Err = err.Error()
+			} else {
+				e.Err = fmt.Sprint(v)
+			}
+			e.HTTPRequest = newHTTPRequest(r, e.logger.trustedProxies())
+			e.HTTPRequest.Status = http.StatusInternalServerError
+			e.logger.log(e, SeverityCritical, fmt.Sprint("panic serving ", r.Method, " ", r.URL.Path), 2)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
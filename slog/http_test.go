@@ -0,0 +1,157 @@
+package slog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func panickingHandler(w http.ResponseWriter, r *http.Request) {
+	panic(errors.New("handler failed"))
+}
+
+func nilHandler(w http.ResponseWriter, r *http.Request) {
+	var m map[string]*Entry
+	w.Header().Set("X-Message", m["missing"].Message)
+}
+
+func TestRecoverHandler(t *testing.T) {
+	tests := []struct {
+		name      string
+		handler   http.HandlerFunc
+		wantErr   string
+		wantFrame string
+	}{
+		{"panic", panickingHandler, "handler failed", "github.com/ParticleHealth/tau/slog.panickingHandler(...)"},
+		{"nil dereference", nilHandler, "runtime error: invalid memory address or nil pointer dereference", "github.com/ParticleHealth/tau/slog.nilHandler(...)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := bytes.NewBuffer(make([]byte, 0, 4096))
+			logger := newLogger(out)
+			logger.SetProject("test-project")
+			entry := logger.WithLabels(Fields{"request": "abc"})
+
+			req := httptest.NewRequest(http.MethodGet, "/patients/123?q=1", nil)
+			req.Header.Set("User-Agent", "test-agent")
+			req.Header.Set("X-Forwarded-For", "203.0.113.1, 10.0.0.1")
+			req = req.WithContext(NewContext(context.Background(), entry))
+			rec := httptest.NewRecorder()
+			RecoverHandler(tt.handler).ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusInternalServerError {
+				t.Errorf("unexpected status\nwant: 500\ngot: %d", rec.Code)
+			}
+			var e Entry
+			if err := json.Unmarshal(out.Bytes(), &e); err != nil {
+				t.Fatalf("decoding entry: %v\n%s", err, out)
+			}
+			if e.Severity != SeverityCritical {
+				t.Errorf("unexpected severity\nwant: CRITICAL\ngot: %s", e.Severity)
+			}
+			if e.Err != tt.wantErr {
+				t.Errorf("unexpected error\nwant: %s\ngot: %s", tt.wantErr, e.Err)
+			}
+			if e.Labels["request"] != "abc" {
+				t.Errorf("entry from context not used: %v", e.Labels)
+			}
+			want := &HTTPRequest{
+				RequestMethod: http.MethodGet,
+				RequestURL:    "/patients/123?q=1",
+				Status:        http.StatusInternalServerError,
+				UserAgent:     "test-agent",
+				RemoteIP:      "192.0.2.1",
+				Protocol:      "HTTP/1.1",
+			}
+			if e.HTTPRequest == nil || *e.HTTPRequest != *want {
+				t.Errorf("unexpected request\nwant: %+v\ngot: %+v", want, e.HTTPRequest)
+			}
+			if fn := strings.TrimSuffix(tt.wantFrame, "(...)"); e.SourceLocation == nil || e.SourceLocation.Function != fn {
+				t.Errorf("source not where the panic was raised\nwant: %s\ngot: %+v", fn, e.SourceLocation)
+			}
+			subTestStack(t, out.String(), tt.wantErr, tt.wantFrame)
+		})
+	}
+}
+
+func TestRecoverHandlerPassesThrough(t *testing.T) {
+	out := bytes.NewBuffer(make([]byte, 0, 1024))
+	logger := newLogger(out)
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req = req.WithContext(NewContext(context.Background(), logger.entry()))
+	rec := httptest.NewRecorder()
+	RecoverHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})).ServeHTTP(rec, req)
+	if rec.Code != http.StatusNoContent || out.Len() != 0 {
+		t.Errorf("handler without panic altered\nstatus: %d\nlog: %s", rec.Code, out)
+	}
+
+	defer func() {
+		if r := recover(); r != http.ErrAbortHandler {
+			t.Errorf("abort not passed on: %v", r)
+		}
+		if strings.Contains(out.String(), "panic") {
+			t.Errorf("abort logged: %s", out)
+		}
+	}()
+	RecoverHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	})).ServeHTTP(rec, req)
+}
+
+func TestWithHTTPRequest(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/fhir", nil)
+	for _, e := range []*Entry{WithHTTPRequest(req), std.WithHTTPRequest(req), base.WithHTTPRequest(req)} {
+		if e.HTTPRequest == nil || e.HTTPRequest.RequestMethod != http.MethodPost || e.HTTPRequest.RemoteIP != "192.0.2.1" {
+			t.Errorf("request not included: %+v", e.HTTPRequest)
+		}
+	}
+	if base.HTTPRequest != nil {
+		t.Error("parent entry modified")
+	}
+}
+
+func TestTrustedProxies(t *testing.T) {
+	logger := newLogger(nil)
+	tests := []struct {
+		name    string
+		proxies []string
+		remote  string
+		fwd     []string
+		want    string
+	}{
+		{"untrusted by default", nil, "192.0.2.1:1234", []string{"203.0.113.1"}, "192.0.2.1"},
+		{"untrusted connection", []string{"10.0.0.0/8"}, "192.0.2.1:1234", []string{"203.0.113.1"}, "192.0.2.1"},
+		{"trusted proxy", []string{"10.0.0.0/8"}, "10.0.0.2:1234", []string{"203.0.113.1"}, "203.0.113.1"},
+		{"forged hop", []string{"10.0.0.0/8"}, "10.0.0.2:1234", []string{"198.51.100.1, 203.0.113.1"}, "203.0.113.1"},
+		{"proxy chain", []string{"10.0.0.0/8", "192.0.2.7"}, "10.0.0.2:1234", []string{"198.51.100.1, 203.0.113.1", "192.0.2.7, 10.0.0.3"}, "203.0.113.1"},
+		{"all trusted", []string{"10.0.0.0/8"}, "10.0.0.2:1234", []string{"10.0.0.3"}, "10.0.0.3"},
+		{"no header", []string{"10.0.0.0/8"}, "10.0.0.2:1234", nil, "10.0.0.2"},
+	}
+	for _, tt := range tests {
+		if err := logger.SetTrustedProxies(tt.proxies...); err != nil {
+			t.Fatalf("%s: setting proxies: %v", tt.name, err)
+		}
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = tt.remote
+		for _, v := range tt.fwd {
+			req.Header.Add("X-Forwarded-For", v)
+		}
+		if got := logger.WithHTTPRequest(req).HTTPRequest.RemoteIP; got != tt.want {
+			t.Errorf("%s: unexpected remote IP\nwant: %s\ngot: %s", tt.name, tt.want, got)
+		}
+	}
+
+	if err := logger.SetTrustedProxies("not an ip"); err == nil {
+		t.Error("expected error for invalid proxy")
+	}
+	if err := logger.SetTrustedProxies("10.0.0.0/33"); err == nil {
+		t.Error("expected error for invalid range")
+	}
+}
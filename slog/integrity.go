@@ -0,0 +1,318 @@
+package slog
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+)
+
+// integrityKey appended to each entry written in integrity mode.
+const integrityKey = `,"integrity":`
+
+// Integrity values appended to each entry.
+type Integrity struct {
+	Seq   uint64 `json:"seq"`
+	Prev  string `json:"prev,omitempty"`
+	Chain string `json:"chain"`
+}
+
+// KeyProvider of the HMAC key used to chain entries.
+type KeyProvider interface {
+	Key() ([]byte, error)
+}
+
+// KeyFunc allows a function to be used as a KeyProvider.
+type KeyFunc func() ([]byte, error)
+
+// Key returned by calling f.
+func (f KeyFunc) Key() ([]byte, error) {
+	return f()
+}
+
+// StaticKey always providing the same key.
+func StaticKey(key []byte) KeyProvider {
+	return KeyFunc(func() ([]byte, error) { return key, nil })
+}
+
+// FileKey read once from the file at path, with surrounding whitespace removed.
+func FileKey(path string) KeyProvider {
+	var (
+		once sync.Once
+		key  []byte
+		err  error
+	)
+	return KeyFunc(func() ([]byte, error) {
+		once.Do(func() {
+			var b []byte
+			b, err = os.ReadFile(path)
+			if err != nil {
+				err = fmt.Errorf("reading integrity key: %w", err)
+				return
+			}
+			key = bytes.TrimSpace(b)
+			if len(key) == 0 {
+				err = fmt.Errorf("integrity key file %s is empty", path)
+			}
+		})
+		return key, err
+	})
+}
+
+// chainValue over an encoded entry, its sequence number and the previous chain value.
+// Uses HMAC-SHA256 when kp is not nil, otherwise plain SHA-256.
+func chainValue(kp KeyProvider, prev string, seq uint64, payload []byte) (string, error) {
+	var h hash.Hash
+	if kp != nil {
+		key, err := kp.Key()
+		if err != nil {
+			return "", err
+		}
+		h = hmac.New(sha256.New, key)
+	} else {
+		h = sha256.New()
+	}
+	h.Write([]byte(prev))
+	h.Write([]byte{'\n'})
+	h.Write(strconv.AppendUint(nil, seq, 10))
+	h.Write([]byte{'\n'})
+	h.Write(payload)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// integrityChain of entries, each chained to the previous one.
+type integrityChain struct {
+	mu    sync.Mutex // guards the chain
+	kp    KeyProvider
+	seq   uint64
+	chain string
+}
+
+// next integrity values for an encoded entry, advancing the chain.
+func (c *integrityChain) next(payload []byte) (*Integrity, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	in := &Integrity{Seq: c.seq + 1, Prev: c.chain}
+	chain, err := chainValue(c.kp, in.Prev, in.Seq, payload)
+	if err != nil {
+		return nil, fmt.Errorf("chaining entry: %w", err)
+	}
+	in.Chain = chain
+	c.seq, c.chain = in.Seq, in.Chain
+	return in, nil
+}
+
+// integrityEncoder wraps a JSON encoder, chaining each entry to the previous one.
+type integrityEncoder struct {
+	enc   Encoder
+	chain integrityChain
+}
+
+// NewIntegrityEncoder wrapping enc, which must write one JSON object per line.
+// Each entry gets a sequence number and a chain value over the entry and the previous chain value,
+// using an HMAC if kp is not nil. See VerifyIntegrity.
+//
+// Without a key the chain is plain SHA-256, which anyone able to edit the log can recompute after changing it.
+// It detects accidental loss or corruption but is not tamper-evident, use a KeyProvider for that.
+func NewIntegrityEncoder(enc Encoder, kp KeyProvider) Encoder {
+	return &integrityEncoder{enc: enc, chain: integrityChain{kp: kp}}
+}
+
+// Encode e with integrity values appended.
+func (ie *integrityEncoder) Encode(buf *bytes.Buffer, e *Entry) error {
+	start := buf.Len()
+	if err := ie.enc.Encode(buf, e); err != nil {
+		return err
+	}
+	payload := bytes.TrimRight(buf.Bytes()[start:], "\n")
+	if len(payload) < 2 || payload[len(payload)-1] != '}' {
+		return errors.New("integrity mode requires JSON objects")
+	}
+
+	in, err := ie.chain.next(payload)
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+
+	empty := len(bytes.TrimSpace(payload[1:len(payload)-1])) == 0
+	buf.Truncate(start + len(payload) - 1)
+	if empty {
+		buf.WriteString(integrityKey[1:])
+	} else {
+		buf.WriteString(integrityKey)
+	}
+	buf.Write(b)
+	buf.WriteString("}\n")
+	return nil
+}
+
+// SetIntegrity mode for the sink, wrapping its current encoder. See NewIntegrityEncoder.
+// Setting it again replaces the existing integrity mode, starting a new chain.
+func (s *Sink) SetIntegrity(kp KeyProvider) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	enc := s.encoder
+	if ie, ok := enc.(*integrityEncoder); ok {
+		enc = ie.enc
+	}
+	s.encoder = NewIntegrityEncoder(enc, kp)
+}
+
+// SetIntegrity mode for all sinks of the logger, each keeping its own chain.
+// Sinks should not be shared with other loggers in integrity mode so entries are written in chain order.
+func (l *Logger) SetIntegrity(kp KeyProvider) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, s := range l.sinks {
+		s.SetIntegrity(kp)
+	}
+}
+
+// SetIntegrity mode for all sinks of the package-level logger, each keeping its own chain.
+func SetIntegrity(kp KeyProvider) {
+	std.SetIntegrity(kp)
+}
+
+// IntegrityReport of problems found verifying a stream of entries.
+type IntegrityReport struct {
+	// Entries verified.
+	Entries int
+	// Missing sequence numbers that were never seen.
+	Missing []uint64
+	// Reordered sequence numbers seen after a later entry.
+	Reordered []uint64
+	// Modified sequence numbers whose chain value does not match their contents or the previous entry.
+	Modified []uint64
+	// Invalid line numbers, starting at 1, without parseable integrity values.
+	Invalid []int
+}
+
+// OK if no problems were found.
+func (r *IntegrityReport) OK() bool {
+	return len(r.Missing) == 0 && len(r.Reordered) == 0 && len(r.Modified) == 0 && len(r.Invalid) == 0
+}
+
+// splitIntegrity from an encoded line, returning the original payload.
+func splitIntegrity(line []byte) ([]byte, *Integrity, error) {
+	i := bytes.LastIndex(line, []byte(integrityKey[1:]))
+	if i < 1 || line[len(line)-1] != '}' {
+		return nil, nil, errors.New("missing integrity values")
+	}
+	var in Integrity
+	if err := json.Unmarshal(line[i+len(integrityKey)-1:len(line)-1], &in); err != nil {
+		return nil, nil, err
+	}
+	payload := make([]byte, 0, i+1)
+	if line[i-1] == ',' {
+		payload = append(payload, line[:i-1]...)
+	} else {
+		payload = append(payload, line[:i]...)
+	}
+	return append(payload, '}'), &in, nil
+}
+
+// VerifyIntegrity of a captured JSONL stream written in integrity mode, using the same KeyProvider.
+// A stream may contain several chains, such as after a restart, each starting at sequence 1.
+func VerifyIntegrity(r io.Reader, kp KeyProvider) (*IntegrityReport, error) {
+	v := &integrityVerifier{kp: kp}
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for n := 1; sc.Scan(); n++ {
+		line := bytes.TrimSpace(sc.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		payload, in, err := splitIntegrity(line)
+		if err != nil {
+			v.invalid(n)
+			continue
+		}
+		if err := v.add(payload, in); err != nil {
+			return nil, fmt.Errorf("verifying line %d: %w", n, err)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("reading stream: %w", err)
+	}
+	return v.finish(), nil
+}
+
+// integrityVerifier of chained entries in the order they were read, collecting problems in a report.
+type integrityVerifier struct {
+	kp               KeyProvider
+	report           IntegrityReport
+	seen             map[uint64]bool
+	first, last, max uint64
+	lastChain        string
+}
+
+// invalid entry without integrity values at position n, starting at 1.
+func (v *integrityVerifier) invalid(n int) {
+	v.report.Entries++
+	v.report.Invalid = append(v.report.Invalid, n)
+}
+
+// add an entry with its payload as chained.
+func (v *integrityVerifier) add(payload []byte, in *Integrity) error {
+	v.report.Entries++
+	chain, err := chainValue(v.kp, in.Prev, in.Seq, payload)
+	if err != nil {
+		return err
+	}
+
+	if in.Seq == 1 && in.Prev == "" {
+		v.missing()
+		v.seen = nil
+		v.first, v.last, v.max = 1, 0, 0
+	} else if v.first == 0 {
+		v.first = in.Seq
+	}
+	switch {
+	case chain != in.Chain:
+		v.report.Modified = append(v.report.Modified, in.Seq)
+	case in.Seq <= v.last:
+		v.report.Reordered = append(v.report.Reordered, in.Seq)
+	case in.Seq == v.last+1 && v.last != 0 && in.Prev != v.lastChain:
+		v.report.Modified = append(v.report.Modified, in.Seq)
+	}
+	if v.seen == nil {
+		v.seen = make(map[uint64]bool)
+	}
+	v.seen[in.Seq] = true
+	if in.Seq > v.max {
+		v.max = in.Seq
+	}
+	v.last, v.lastChain = in.Seq, in.Chain
+	return nil
+}
+
+// missing sequence numbers of the current chain added to the report.
+func (v *integrityVerifier) missing() {
+	if v.max == 0 {
+		return
+	}
+	for seq := v.first; seq <= v.max; seq++ {
+		if !v.seen[seq] {
+			v.report.Missing = append(v.report.Missing, seq)
+		}
+	}
+}
+
+// finish verifying, returning the report.
+func (v *integrityVerifier) finish() *IntegrityReport {
+	v.missing()
+	return &v.report
+}
@@ -0,0 +1,109 @@
+package slog
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func integrityLines(t *testing.T, kp KeyProvider, n int) []string {
+	t.Helper()
+	out := bytes.NewBuffer(make([]byte, 0, 4096))
+	logger := newLogger(out)
+	logger.SetIntegrity(kp)
+	for i := 0; i < n; i++ {
+		logger.WithDetail("i", i).Info("entry")
+	}
+	lines := strings.SplitAfter(out.String(), "\n")
+	return lines[:len(lines)-1]
+}
+
+func TestIntegrityEncoding(t *testing.T) {
+	lines := integrityLines(t, nil, 2)
+	for i, line := range lines {
+		var got struct {
+			Message   string    `json:"message"`
+			Integrity Integrity `json:"integrity"`
+		}
+		if err := json.Unmarshal([]byte(line), &got); err != nil {
+			t.Fatalf("entry not valid JSON: %v\n%s", err, line)
+		}
+		if got.Message != "entry" || got.Integrity.Seq != uint64(i+1) || got.Integrity.Chain == "" {
+			t.Errorf("unexpected entry: %+v", got)
+		}
+	}
+
+	buf := bytes.NewBuffer(nil)
+	enc := NewIntegrityEncoder(EncoderFunc(func(buf *bytes.Buffer, e *Entry) error {
+		buf.WriteString("{}\n")
+		return nil
+	}), nil)
+	if err := enc.Encode(buf, &Entry{}); err != nil {
+		t.Fatalf("encoding empty object: %v", err)
+	}
+	if !json.Valid(buf.Bytes()) {
+		t.Errorf("empty object not valid JSON: %s", buf)
+	}
+
+	out := bytes.NewBuffer(make([]byte, 0, 1024))
+	logger := newLogger(out)
+	logger.SetIntegrity(nil)
+	logger.SetIntegrity(StaticKey([]byte("secret")))
+	logger.Info("entry")
+	if got := strings.Count(out.String(), `"integrity"`); got != 1 {
+		t.Errorf("integrity mode applied more than once: %s", out)
+	}
+	if report, err := VerifyIntegrity(out, StaticKey([]byte("secret"))); err != nil || !report.OK() {
+		t.Errorf("entry not chained with replaced key: %+v, %v", report, err)
+	}
+}
+
+func TestVerifyIntegrity(t *testing.T) {
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "key")
+	if err := os.WriteFile(keyFile, []byte("secret\n"), 0o600); err != nil {
+		t.Fatalf("writing key: %v", err)
+	}
+	kp := FileKey(keyFile)
+	lines := integrityLines(t, kp, 5)
+	tampered := strings.Replace(lines[2], `"i":2`, `"i":9`, 1)
+
+	tests := []struct {
+		name  string
+		lines []string
+		kp    KeyProvider
+		want  IntegrityReport
+	}{
+		{"valid", lines, kp, IntegrityReport{Entries: 5}},
+		{"restarted", append(append([]string{}, lines...), lines...), kp, IntegrityReport{Entries: 10}},
+		{"missing", []string{lines[0], lines[1], lines[3], lines[4]}, kp, IntegrityReport{Entries: 4, Missing: []uint64{3}}},
+		{"reordered", []string{lines[0], lines[2], lines[1], lines[3], lines[4]}, kp, IntegrityReport{Entries: 5, Reordered: []uint64{2}}},
+		{"modified", []string{lines[0], lines[1], tampered, lines[3], lines[4]}, kp, IntegrityReport{Entries: 5, Modified: []uint64{3}}},
+		{"invalid", []string{lines[0], `{"message":"inserted"}` + "\n", lines[1]}, kp, IntegrityReport{Entries: 3, Invalid: []int{2}}},
+		{"wrong key", lines[:1], StaticKey([]byte("other")), IntegrityReport{Entries: 1, Modified: []uint64{1}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := VerifyIntegrity(strings.NewReader(strings.Join(tt.lines, "")), tt.kp)
+			if err != nil {
+				t.Fatalf("verifying: %v", err)
+			}
+			if diff := cmp.Diff(&tt.want, got, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("unexpected report:\n%s", diff)
+			}
+			if got.OK() != (tt.name == "valid" || tt.name == "restarted") {
+				t.Errorf("unexpected OK: %v", got.OK())
+			}
+		})
+	}
+
+	if _, err := VerifyIntegrity(strings.NewReader(lines[0]), FileKey(filepath.Join(dir, "missing"))); err == nil {
+		t.Error("expected error for missing key file")
+	}
+}
@@ -0,0 +1,124 @@
+package slog
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// Environment variables set for each Cloud Run Jobs task.
+const (
+	envTaskAttempt = "CLOUD_RUN_TASK_ATTEMPT"
+	envTaskCount   = "CLOUD_RUN_TASK_COUNT"
+)
+
+// Labels populated by TaskEntry in addition to LabelTaskIndex.
+const (
+	LabelTaskAttempt = "task_attempt"
+	LabelTaskCount   = "task_count"
+)
+
+// Task statuses included in the summary written by RunTask.
+const (
+	TaskSucceeded = "succeeded"
+	TaskFailed    = "failed"
+	TaskPanicked  = "panicked"
+)
+
+// taskEntry with the Cloud Run Jobs task labels set.
+func (l *Logger) taskEntry() *Entry {
+	labels := make(map[string]string, 3)
+	for env, label := range map[string]string{
+		envTaskIndex:   LabelTaskIndex,
+		envTaskAttempt: LabelTaskAttempt,
+		envTaskCount:   LabelTaskCount,
+	} {
+		if v := os.Getenv(env); v != "" {
+			labels[label] = v
+		}
+	}
+	e := l.entry()
+	if len(labels) > 0 {
+		e.Labels = labels
+	}
+	return e
+}
+
+// TaskEntry with the Cloud Run Jobs task index, attempt and count included as labels.
+func (l *Logger) TaskEntry() *Entry {
+	return l.taskEntry()
+}
+
+// TaskEntry with the Cloud Run Jobs task index, attempt and count included as labels.
+func TaskEntry() *Entry {
+	return std.taskEntry()
+}
+
+// taskOperation ID and producer unique to a single attempt of a task.
+func taskOperation() (id, producer string) {
+	producer = os.Getenv(envJob)
+	if producer == "" {
+		producer = "job"
+	}
+	execution := os.Getenv(envExecution)
+	if execution == "" {
+		execution = producer
+	}
+	return fmt.Sprint(execution, "/", os.Getenv(envTaskIndex), "/", os.Getenv(envTaskAttempt)), producer
+}
+
+// runTask in an operation, logging a summary once it returns.
+func (l *Logger) runTask(fn func(e *Entry) error) (err error) {
+	id, producer := taskOperation()
+	e := l.taskEntry().StartOperation(id, producer)
+	start := time.Now()
+	status := TaskSucceeded
+	defer func() {
+		var st stack
+		if r := recover(); r != nil {
+			status = TaskPanicked
+			st = panicStack()
+			err = fmt.Errorf("task panicked: %v", r)
+		}
+		took := time.Since(start)
+		summary := e.WithError(err).WithDetails(Fields{
+			"status":   status,
+			"duration": took.String(),
+		})
+		if st != nil {
+			// The stack and source location are where the panic was raised, for Error Reporting.
+			summary.stack = st
+			summary.stackSource = true
+		}
+		msg := fmt.Sprint("task ", id, " ", status, " after ", took)
+		switch status {
+		case TaskPanicked:
+			summary.Critical(msg)
+		case TaskFailed:
+			summary.Error(msg)
+		default:
+			summary.Notice(msg)
+		}
+		e.EndOperation()
+	}()
+	if err = fn(e); err != nil {
+		status = TaskFailed
+	}
+	return err
+}
+
+// RunTask fn as a single Cloud Run Jobs task attempt.
+// All logs written with the provided Entry are part of one operation and carry the task labels.
+// Once fn returns a summary is logged at Notice, Error on failure or Critical if fn panicked.
+// A recovered panic is returned as an error so the caller can set the exit code.
+func (l *Logger) RunTask(fn func(e *Entry) error) error {
+	return l.runTask(fn)
+}
+
+// RunTask fn as a single Cloud Run Jobs task attempt.
+// All logs written with the provided Entry are part of one operation and carry the task labels.
+// Once fn returns a summary is logged at Notice, Error on failure or Critical if fn panicked.
+// A recovered panic is returned as an error so the caller can set the exit code.
+func RunTask(fn func(e *Entry) error) error {
+	return std.runTask(fn)
+}
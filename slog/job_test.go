@@ -0,0 +1,108 @@
+package slog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func setTaskEnv(t *testing.T) {
+	t.Helper()
+	t.Setenv(envJob, "batch")
+	t.Setenv(envExecution, "batch-abc")
+	t.Setenv(envTaskIndex, "2")
+	t.Setenv(envTaskAttempt, "1")
+	t.Setenv(envTaskCount, "5")
+}
+
+func decodeEntries(t *testing.T, b []byte) []Entry {
+	t.Helper()
+	var entries []Entry
+	dec := json.NewDecoder(bytes.NewReader(b))
+	for dec.More() {
+		var e Entry
+		if err := dec.Decode(&e); err != nil {
+			t.Fatalf("decoding entry: %v", err)
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+func TestTaskEntry(t *testing.T) {
+	setTaskEnv(t)
+	TaskEntry().Info("testing")
+	got := buf.String()
+	buf.Reset()
+	for _, want := range []string{`"task_index":"2"`, `"task_attempt":"1"`, `"task_count":"5"`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("task label not included\nwant: %s\ngot: %s", want, got)
+		}
+	}
+}
+
+func TestRunTask(t *testing.T) {
+	setTaskEnv(t)
+	tests := []struct {
+		name     string
+		fn       func(e *Entry) error
+		severity Severity
+		status   string
+		err      bool
+	}{
+		{"success", func(e *Entry) error { e.Info("working"); return nil }, SeverityNotice, TaskSucceeded, false},
+		{"failure", func(e *Entry) error { return errors.New("failed") }, SeverityError, TaskFailed, true},
+		{"panic", func(e *Entry) error { panic("oh no") }, SeverityCritical, TaskPanicked, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := bytes.NewBuffer(make([]byte, 0, 4096))
+			logger := newLogger(out)
+			err := logger.RunTask(tt.fn)
+			if (err != nil) != tt.err {
+				t.Errorf("unexpected error: %v", err)
+			}
+			entries := decodeEntries(t, out.Bytes())
+			if len(entries) < 3 {
+				t.Fatalf("expected start, summary and end entries, got: %s", out)
+			}
+			for _, e := range entries {
+				if e.Operation == nil || e.Operation.ID != "batch-abc/2/1" || e.Operation.Producer != "batch" {
+					t.Errorf("entry not part of task operation: %+v", e.Operation)
+				}
+				if e.Labels[LabelTaskIndex] != "2" {
+					t.Errorf("entry missing task labels: %v", e.Labels)
+				}
+			}
+			if !entries[0].Operation.First || !entries[len(entries)-1].Operation.Last {
+				t.Errorf("operation not started and ended: %s", out)
+			}
+			summary := entries[len(entries)-2]
+			if summary.Severity != tt.severity {
+				t.Errorf("unexpected summary severity\nwant: %s\ngot: %s", tt.severity, summary.Severity)
+			}
+			if summary.Details["status"] != tt.status {
+				t.Errorf("unexpected summary status\nwant: %s\ngot: %v", tt.status, summary.Details["status"])
+			}
+			if _, ok := summary.Details["duration"]; !ok {
+				t.Errorf("summary missing duration: %v", summary.Details)
+			}
+			if tt.err && summary.Err == "" {
+				t.Error("summary missing error")
+			}
+			if msg := summary.Message; !strings.HasSuffix(msg, " after "+summary.Details["duration"].(string)) {
+				t.Errorf("summary message and duration disagree: %q, %v", msg, summary.Details["duration"])
+			}
+			if tt.status == TaskPanicked {
+				if fn := summary.SourceLocation.Function; !strings.Contains(fn, "TestRunTask") {
+					t.Errorf("panic source not where it was raised: %s", fn)
+				}
+				if frames := strings.SplitN(summary.StackTrace, "\n", 5); len(frames) < 4 || !strings.Contains(frames[3], "TestRunTask") {
+					t.Errorf("panic stack starts in the recovering function:\n%s", summary.StackTrace)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package slog
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"syscall"
+)
+
+// JournaldSocket of the native journal protocol.
+const JournaldSocket = "/run/systemd/journal/socket"
+
+// JournaldConfig for a Sink writing to the systemd journal.
+type JournaldConfig struct {
+	// Socket of the journal, defaults to JournaldSocket.
+	Socket string
+	// Identifier for SYSLOG_IDENTIFIER, defaults to the executable name.
+	Identifier string
+	// QueueSize of entries waiting to be written, any more are dropped.
+	// Entries are written synchronously if zero.
+	QueueSize int
+}
+
+// NewJournaldSink writing entries to the systemd journal using its native protocol.
+// Labels and details are written as fields prefixed LABEL_ and DETAIL_ with names upper-cased,
+// so they can be matched with journalctl, such as journalctl LABEL_SERVICE=records.
+// Each entry is sent as a single datagram, limited by the socket send buffer (net.core.wmem_max on Linux) which
+// is usually a few hundred kilobytes. Larger entries are dropped, reported by Sink.Dropped.
+func NewJournaldSink(cfg JournaldConfig) (*Sink, error) {
+	if cfg.Socket == "" {
+		cfg.Socket = JournaldSocket
+	}
+	conn, err := net.Dial("unixgram", cfg.Socket)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to journal: %w", err)
+	}
+	jc := &journalConn{Conn: conn}
+	var s *Sink
+	if cfg.QueueSize > 0 {
+		s = NewAsyncSink(jc, cfg.QueueSize)
+	} else {
+		s = NewSink(jc)
+	}
+	s.owned = true
+	s.SetEncoder(NewJournaldEncoder(cfg.Identifier))
+	return s, nil
+}
+
+// journalConn to the journal socket, counting entries too large to send.
+type journalConn struct {
+	dropped uint64 // first to keep 64-bit alignment for atomic access
+	net.Conn
+}
+
+// Write an entry as a single datagram, dropping it if it is too large.
+func (c *journalConn) Write(p []byte) (int, error) {
+	n, err := c.Conn.Write(p)
+	if errors.Is(err, syscall.EMSGSIZE) {
+		atomic.AddUint64(&c.dropped, 1)
+		return n, fmt.Errorf("dropped entry of %d bytes too large for the journal socket: %w", len(p), err)
+	}
+	return n, err
+}
+
+// Dropped returns the number of entries too large to send.
+func (c *journalConn) Dropped() uint64 {
+	return atomic.LoadUint64(&c.dropped)
+}
+
+// NewJournaldEncoder writing entries in the native journal protocol with the given SYSLOG_IDENTIFIER.
+// See https://systemd.io/JOURNAL_NATIVE_PROTOCOL/ for reference.
+func NewJournaldEncoder(identifier string) Encoder {
+	if identifier == "" {
+		identifier = filepath.Base(os.Args[0])
+	}
+	return EncoderFunc(func(buf *bytes.Buffer, e *Entry) error {
+		writeJournalField(buf, "MESSAGE", e.Message)
+		writeJournalField(buf, "PRIORITY", fmt.Sprint(syslogSeverity(e.Severity)))
+		writeJournalField(buf, "SYSLOG_IDENTIFIER", identifier)
+		if e.Severity != SeverityDefault {
+			writeJournalField(buf, "SEVERITY", e.Severity.String())
+		}
+		if e.Err != "" {
+			writeJournalField(buf, "ERROR", e.Err)
+		}
+		if e.StackTrace != "" {
+			writeJournalField(buf, "STACK_TRACE", e.StackTrace)
+		}
+		if s := e.SourceLocation; s != nil {
+			writeJournalField(buf, "CODE_FILE", s.File)
+			writeJournalField(buf, "CODE_LINE", s.Line)
+			writeJournalField(buf, "CODE_FUNC", s.Function)
+		}
+		if e.Trace != "" {
+			writeJournalField(buf, "TRACE_ID", e.traceID())
+			writeJournalField(buf, "SPAN_ID", e.SpanID)
+		}
+		if o := e.Operation; o != nil {
+			writeJournalField(buf, "OPERATION_ID", o.ID)
+			writeJournalField(buf, "OPERATION_PRODUCER", o.Producer)
+		}
+		writeJournalFields(buf, "LABEL_", len(e.Labels), func(f func(k, v string)) {
+			for k, v := range e.Labels {
+				f(k, v)
+			}
+		})
+		details := e.mergedDetails()
+		writeJournalFields(buf, "DETAIL_", len(details), func(f func(k, v string)) {
+			for k, v := range details {
+				f(k, detailString(e.detail(v)))
+			}
+		})
+		return nil
+	})
+}
+
+// writeJournalFields with names prefixed and sorted so output is stable.
+func writeJournalFields(buf *bytes.Buffer, prefix string, n int, each func(f func(k, v string))) {
+	if n == 0 {
+		return
+	}
+	fields := make(map[string]string, n)
+	each(func(k, v string) {
+		fields[journalName(prefix+k)] = v
+	})
+	names := make([]string, 0, len(fields))
+	for k := range fields {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		writeJournalField(buf, k, fields[k])
+	}
+}
+
+// journalName upper-cased with characters other than letters, digits and underscores replaced,
+// limited to the 64 characters the journal allows.
+func journalName(k string) string {
+	b := []byte(strings.ToUpper(k))
+	for i, c := range b {
+		if (c < 'A' || c > 'Z') && (c < '0' || c > '9') {
+			b[i] = '_'
+		}
+	}
+	if len(b) > 64 {
+		b = b[:64]
+	}
+	return string(b)
+}
+
+// writeJournalField as KEY=value, or with an explicit length if the value contains a newline.
+func writeJournalField(buf *bytes.Buffer, k, v string) {
+	buf.WriteString(k)
+	if strings.IndexByte(v, '\n') < 0 {
+		buf.WriteByte('=')
+		buf.WriteString(v)
+		buf.WriteByte('\n')
+		return
+	}
+	buf.WriteByte('\n')
+	var size [8]byte
+	binary.LittleEndian.PutUint64(size[:], uint64(len(v)))
+	buf.Write(size[:])
+	buf.WriteString(v)
+	buf.WriteByte('\n')
+}
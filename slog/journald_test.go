@@ -0,0 +1,118 @@
+package slog
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// parseJournal fields from a native protocol datagram.
+func parseJournal(t *testing.T, b []byte) map[string]string {
+	t.Helper()
+	fields := make(map[string]string)
+	for len(b) > 0 {
+		i := bytes.IndexAny(b, "=\n")
+		if i < 0 {
+			t.Fatalf("truncated field: %q", b)
+		}
+		k := string(b[:i])
+		if b[i] == '=' {
+			end := bytes.IndexByte(b[i:], '\n')
+			fields[k] = string(b[i+1 : i+end])
+			b = b[i+end+1:]
+			continue
+		}
+		n := int(binary.LittleEndian.Uint64(b[i+1 : i+9]))
+		fields[k] = string(b[i+9 : i+9+n])
+		if b[i+9+n] != '\n' {
+			t.Fatalf("missing newline after %s", k)
+		}
+		b = b[i+10+n:]
+	}
+	return fields
+}
+
+func TestJournaldSink(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "journal")
+	conn, err := net.ListenPacket("unixgram", socket)
+	if err != nil {
+		t.Fatalf("listening: %v", err)
+	}
+	defer conn.Close()
+
+	sink, err := NewJournaldSink(JournaldConfig{Socket: socket, Identifier: "worker"})
+	if err != nil {
+		t.Fatalf("creating sink: %v", err)
+	}
+	defer sink.Close()
+	logger := New(sink)
+	logger.WithLabels(Fields{"service": "records", "task-index": "0"}).
+		WithDetail("patient", "abc").
+		With(Duration("took", time.Second)).
+		Error("multi\nline")
+
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	msg := make([]byte, 64*1024)
+	n, _, err := conn.ReadFrom(msg)
+	if err != nil {
+		t.Fatalf("reading entry: %v", err)
+	}
+	got := parseJournal(t, msg[:n])
+	want := map[string]string{
+		"MESSAGE":           "multi\nline",
+		"PRIORITY":          "3",
+		"SEVERITY":          "ERROR",
+		"SYSLOG_IDENTIFIER": "worker",
+		"CODE_FUNC":         "github.com/ParticleHealth/tau/slog.TestJournaldSink",
+		"LABEL_SERVICE":     "records",
+		"LABEL_TASK_INDEX":  "0",
+		"DETAIL_PATIENT":    "abc",
+		"DETAIL_TOOK":       "1s",
+		"STACK_TRACE":       got["STACK_TRACE"],
+		"CODE_FILE":         got["CODE_FILE"],
+		"CODE_LINE":         got["CODE_LINE"],
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected fields:\n%s", diff)
+	}
+	if !strings.HasSuffix(got["CODE_FILE"], "journald_test.go") || !strings.Contains(got["STACK_TRACE"], "TestJournaldSink") {
+		t.Errorf("unexpected source or stack: %s\n%s", got["CODE_FILE"], got["STACK_TRACE"])
+	}
+
+	// Entries larger than the socket allows are dropped and counted, later entries are still sent.
+	logger.WithDetail("padding", strings.Repeat("x", 16*1024*1024)).Info("too large")
+	logger.Info("after")
+	if got := sink.Dropped(); got != 1 {
+		t.Errorf("oversize entry not counted\nwant: 1\ngot: %d", got)
+	}
+	n, _, err = conn.ReadFrom(msg)
+	if err != nil {
+		t.Fatalf("reading entry: %v", err)
+	}
+	if got := parseJournal(t, msg[:n]); got["MESSAGE"] != "after" {
+		t.Errorf("unexpected entry after oversize one: %q", got["MESSAGE"])
+	}
+
+	if _, err := NewJournaldSink(JournaldConfig{Socket: filepath.Join(t.TempDir(), "missing")}); err == nil {
+		t.Error("expected error for missing socket")
+	}
+}
+
+func TestJournalName(t *testing.T) {
+	for in, want := range map[string]string{
+		"LABEL_service":                     "LABEL_SERVICE",
+		"DETAIL_a.b-c d":                    "DETAIL_A_B_C_D",
+		"DETAIL_émoji":                      "DETAIL___MOJI",
+		"DETAIL_" + strings.Repeat("x", 80): "DETAIL_" + strings.Repeat("X", 57),
+	} {
+		if got := journalName(in); got != want {
+			t.Errorf("unexpected name for %s\nwant: %s\ngot: %s", in, want, got)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package slog
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+)
+
+// MeasureEntries recorded for every entry written, tagged by severity and any metric labels.
+var MeasureEntries = stats.Int64("github.com/ParticleHealth/tau/slog/entries", "Number of log entries written", stats.UnitDimensionless)
+
+// KeySeverity tag recorded with MeasureEntries.
+var KeySeverity = tag.MustNewKey("severity")
+
+// EntriesView counting entries by severity. Register with view.Register to export it.
+var EntriesView = &view.View{
+	Name:        "github.com/ParticleHealth/tau/slog/entries",
+	Description: "Count of log entries by severity",
+	Measure:     MeasureEntries,
+	Aggregation: view.Count(),
+	TagKeys:     []tag.Key{KeySeverity},
+}
+
+// NewEntriesView counting entries by severity and the given labels, which must be set with SetMetricLabels.
+func NewEntriesView(name string, labels ...string) (*view.View, error) {
+	keys := []tag.Key{KeySeverity}
+	for _, label := range labels {
+		k, err := tag.NewKey(label)
+		if err != nil {
+			return nil, fmt.Errorf("creating tag key for label %s: %w", label, err)
+		}
+		keys = append(keys, k)
+	}
+	return &view.View{
+		Name:        name,
+		Description: "Count of log entries by severity and labels",
+		Measure:     MeasureEntries,
+		Aggregation: view.Count(),
+		TagKeys:     keys,
+	}, nil
+}
+
+// EntryCounts written by a Logger, for health endpoints and tests.
+type EntryCounts struct {
+	Total int64
+	// Severity names to the number of entries written at that severity.
+	Severity map[string]int64
+	// Labels set with SetMetricLabels to label values to the number of entries written with that value.
+	Labels map[string]map[string]int64
+}
+
+// counters kept by every Logger. Severities are indexed by value / 100, unknown values count as DEFAULT.
+type counters struct {
+	severities [9]int64
+
+	mu     sync.Mutex // guards labels
+	labels map[string]map[string]int64
+}
+
+// metricLabel whose value is recorded with each entry.
+type metricLabel struct {
+	name string
+	key  tag.Key
+}
+
+// SetMetricLabels whose values are counted and tagged on MeasureEntries in addition to severity.
+// Returns an error if a label is not a valid tag key.
+func (l *Logger) SetMetricLabels(labels ...string) error {
+	ml := make([]metricLabel, 0, len(labels))
+	for _, label := range labels {
+		k, err := tag.NewKey(label)
+		if err != nil {
+			return fmt.Errorf("creating tag key for label %s: %w", label, err)
+		}
+		ml = append(ml, metricLabel{name: label, key: k})
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.metricLabels = ml
+	return nil
+}
+
+// SetMetricLabels whose values are counted and tagged on MeasureEntries in addition to severity
+// for the package-level logger. Returns an error if a label is not a valid tag key.
+func SetMetricLabels(labels ...string) error {
+	return std.SetMetricLabels(labels...)
+}
+
+// metricValues of the metric labels from a set of labels. Must be called with mu held.
+func (l *Logger) metricValues(labels map[string]string) []string {
+	if len(l.metricLabels) == 0 {
+		return nil
+	}
+	values := make([]string, len(l.metricLabels))
+	for i, ml := range l.metricLabels {
+		values[i] = labels[ml.name]
+	}
+	return values
+}
+
+// count an entry written at a given severity with values for the given metric labels.
+func (l *Logger) count(s Severity, labels []metricLabel, values []string) {
+	i := int(s) / 100
+	if i < 0 || i >= len(l.counters.severities) {
+		i = 0
+	}
+	atomic.AddInt64(&l.counters.severities[i], 1)
+	ctx := severityContexts[i]
+	if s != Severity(i*100) {
+		ctx = severityContext(s)
+	}
+	if len(labels) > 0 {
+		mutators := make([]tag.Mutator, len(labels))
+		l.counters.mu.Lock()
+		if l.counters.labels == nil {
+			l.counters.labels = make(map[string]map[string]int64)
+		}
+		for i, ml := range labels {
+			m := l.counters.labels[ml.name]
+			if m == nil {
+				m = make(map[string]int64)
+				l.counters.labels[ml.name] = m
+			}
+			m[values[i]]++
+			mutators[i] = tag.Upsert(ml.key, values[i])
+		}
+		l.counters.mu.Unlock()
+		_ = stats.RecordWithTags(ctx, mutators, MeasureEntries.M(1))
+		return
+	}
+	// Without labels the tags are already in the context, so nothing is allocated when no view is registered.
+	stats.Record(ctx, entryMeasurement...)
+}
+
+// entryMeasurement recorded for each entry, built once as measurements are not modified.
+var entryMeasurement = []stats.Measurement{MeasureEntries.M(1)}
+
+// severityContexts tagged with each severity, indexed by value / 100.
+var severityContexts [9]context.Context
+
+func init() {
+	for i := range severityContexts {
+		severityContexts[i] = severityContext(Severity(i * 100))
+	}
+}
+
+// severityContext tagged with a severity.
+func severityContext(s Severity) context.Context {
+	ctx, err := tag.New(context.Background(), tag.Upsert(KeySeverity, s.String()))
+	if err != nil {
+		return context.Background()
+	}
+	return ctx
+}
+
+// Counts of entries written by the logger since it was created.
+func (l *Logger) Counts() EntryCounts {
+	c := EntryCounts{Severity: make(map[string]int64)}
+	for _, s := range []Severity{
+		SeverityDebug, SeverityInfo, SeverityNotice, SeverityWarning,
+		SeverityError, SeverityCritical, SeverityAlert, SeverityEmergency,
+	} {
+		n := atomic.LoadInt64(&l.counters.severities[s/100])
+		c.Severity[s.String()] = n
+		c.Total += n
+	}
+	if n := atomic.LoadInt64(&l.counters.severities[0]); n > 0 {
+		c.Severity["DEFAULT"] = n
+		c.Total += n
+	}
+	l.counters.mu.Lock()
+	defer l.counters.mu.Unlock()
+	if len(l.counters.labels) > 0 {
+		c.Labels = make(map[string]map[string]int64, len(l.counters.labels))
+		for k, values := range l.counters.labels {
+			c.Labels[k] = make(map[string]int64, len(values))
+			for v, n := range values {
+				c.Labels[k][v] = n
+			}
+		}
+	}
+	return c
+}
+
+// Counts of entries written by the package-level logger since it was created.
+func Counts() EntryCounts {
+	return std.Counts()
+}
@@ -0,0 +1,83 @@
+package slog
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"go.opencensus.io/stats/view"
+)
+
+func TestCounts(t *testing.T) {
+	logger := newLogger(bytes.NewBuffer(make([]byte, 0, 4096)))
+	if err := logger.SetMetricLabels("service"); err != nil {
+		t.Fatalf("setting metric labels: %v", err)
+	}
+	logger.SetLabels(Fields{"service": "svc"})
+	logger.Info("one")
+	logger.Info("two")
+	logger.WithLabels(Fields{"service": "other"}).Error("three")
+	logger.StartOperation("id", "producer")
+
+	got := logger.Counts()
+	want := EntryCounts{
+		Total: 4,
+		Severity: map[string]int64{
+			"DEBUG": 0, "INFO": 2, "NOTICE": 1, "WARNING": 0,
+			"ERROR": 1, "CRITICAL": 0, "ALERT": 0, "EMERGENCY": 0,
+		},
+		Labels: map[string]map[string]int64{"service": {"svc": 3, "other": 1}},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected counts:\n%s", diff)
+	}
+
+	if err := logger.SetMetricLabels("bad\x00key"); err == nil {
+		t.Error("expected error for invalid label")
+	}
+}
+
+func TestEntriesView(t *testing.T) {
+	v, err := NewEntriesView("test/entries", "team")
+	if err != nil {
+		t.Fatalf("creating view: %v", err)
+	}
+	if err := view.Register(v); err != nil {
+		t.Fatalf("registering view: %v", err)
+	}
+	defer view.Unregister(v)
+
+	logger := newLogger(bytes.NewBuffer(make([]byte, 0, 4096)))
+	if err := logger.SetMetricLabels("team"); err != nil {
+		t.Fatalf("setting metric labels: %v", err)
+	}
+	logger.WithLabels(Fields{"team": "records"}).Warn("one")
+	logger.WithLabels(Fields{"team": "records"}).Warn("two")
+	logger.Warn("three")
+
+	rows, err := view.RetrieveData(v.Name)
+	if err != nil {
+		t.Fatalf("retrieving data: %v", err)
+	}
+	got := make(map[string]int64)
+	for _, row := range rows {
+		var sev, team string
+		for _, tag := range row.Tags {
+			switch tag.Key {
+			case KeySeverity:
+				sev = tag.Value
+			default:
+				team = tag.Value
+			}
+		}
+		got[sev+"/"+team] = row.Data.(*view.CountData).Value
+	}
+	want := map[string]int64{"WARNING/records": 2, "WARNING/": 1}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected view data:\n%s", diff)
+	}
+
+	if _, err := NewEntriesView("test/bad", "bad\x00key"); err == nil {
+		t.Error("expected error for invalid label")
+	}
+}
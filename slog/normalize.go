@@ -0,0 +1,216 @@
+package slog
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// cycleValue written in place of a value that contains itself.
+const cycleValue = "<cycle>"
+
+// normalizer of detail values into forms that encode readably, applied when an entry is encoded.
+// Protocol buffer messages use protojson, durations are written as strings such as "1.5s", times in RFC 3339,
+// errors as their message and byte slices as strings when they hold UTF-8. Maps and slices of interface values
+// are normalized element by element with cycles cut, anything else is encoded by encoding/json. Values it cannot
+// encode are written as a placeholder naming their type.
+type normalizer struct {
+	stringers bool // values implementing fmt.Stringer are written with String
+}
+
+// value normalized for encoding. Values are returned as is when they need no change.
+func (n normalizer) value(v interface{}) interface{} {
+	return n.walk(v, nil)
+}
+
+// walk a value, normalizing any nested values. Maps, slices and pointers being walked are tracked in seen.
+func (n normalizer) walk(v interface{}, seen map[uintptr]bool) interface{} {
+	switch t := v.(type) {
+	case nil, string, bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64,
+		json.Number, json.RawMessage:
+		return v
+	case float64:
+		if s, ok := nonFinite(t); ok {
+			return s
+		}
+		return v
+	case float32:
+		if s, ok := nonFinite(float64(t)); ok {
+			return s
+		}
+		return v
+	case proto.Message:
+		if isNil(v) {
+			return nil
+		}
+		b, err := protojson.Marshal(t)
+		if err != nil {
+			return fmt.Sprint("could not marshal ", proto.MessageName(t), ": ", err)
+		}
+		return json.RawMessage(b)
+	case time.Duration:
+		return t.String()
+	case *time.Duration:
+		if t == nil {
+			return nil
+		}
+		return t.String()
+	case time.Time:
+		return t.Format(time.RFC3339Nano)
+	case error:
+		if isNil(v) {
+			return nil
+		}
+		return t.Error()
+	case []byte:
+		if t != nil && utf8.Valid(t) {
+			return string(t)
+		}
+		return v
+	case map[string]interface{}:
+		return n.fields(t, seen)
+	case Fields:
+		return n.fields(t, seen)
+	case []interface{}:
+		if len(t) == 0 {
+			return v
+		}
+		if seen = visit(seen, t); seen == nil {
+			return cycleValue
+		}
+		defer delete(seen, reflect.ValueOf(t).Pointer())
+		a := make([]interface{}, len(t))
+		for i, x := range t {
+			a[i] = n.walk(x, seen)
+		}
+		return a
+	case json.Marshaler, encoding.TextMarshaler:
+		// Types that control their own encoding are left to it.
+		return v
+	case fmt.Stringer:
+		if n.stringers && !isNil(v) {
+			return t.String()
+		}
+	}
+
+	// Pointers to maps and slices walked above are followed, so cycles through them are cut.
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		switch rv.Elem().Interface().(type) {
+		case map[string]interface{}, Fields, []interface{}:
+			if seen = visit(seen, v); seen == nil {
+				return cycleValue
+			}
+			defer delete(seen, rv.Pointer())
+			return n.walk(rv.Elem().Interface(), seen)
+		}
+	}
+
+	// Anything else is encoded by encoding/json here, so a value it cannot encode does not drop the entry.
+	b, err := json.Marshal(v)
+	if err != nil {
+		return unsupported(v, err)
+	}
+	return json.RawMessage(b)
+}
+
+// fields of a map normalized.
+func (n normalizer) fields(m map[string]interface{}, seen map[uintptr]bool) interface{} {
+	if m == nil {
+		return nil
+	}
+	if seen = visit(seen, m); seen == nil {
+		return cycleValue
+	}
+	defer delete(seen, reflect.ValueOf(m).Pointer())
+	c := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		c[k] = n.walk(v, seen)
+	}
+	return c
+}
+
+// visit a map, slice or pointer, returning nil if it is already being walked.
+func visit(seen map[uintptr]bool, v interface{}) map[uintptr]bool {
+	p := reflect.ValueOf(v).Pointer()
+	if seen == nil {
+		seen = make(map[uintptr]bool)
+	}
+	if seen[p] {
+		return nil
+	}
+	seen[p] = true
+	return seen
+}
+
+// unsupported placeholder for a value encoding/json could not encode. Never includes the value itself,
+// which could hold memory addresses.
+func unsupported(v interface{}, err error) string {
+	var uv *json.UnsupportedValueError
+	if errors.As(err, &uv) && strings.HasPrefix(uv.Str, "encountered a cycle") {
+		return cycleValue
+	}
+	return fmt.Sprintf("<unsupported %T>", v)
+}
+
+// nonFinite floats as the strings "NaN", "+Inf" or "-Inf", as JSON cannot encode them.
+func nonFinite(v float64) (string, bool) {
+	switch {
+	case math.IsNaN(v):
+		return "NaN", true
+	case math.IsInf(v, 1):
+		return "+Inf", true
+	case math.IsInf(v, -1):
+		return "-Inf", true
+	}
+	return "", false
+}
+
+// isNil reports whether v holds a nil pointer, map, slice or similar.
+func isNil(v interface{}) bool {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface, reflect.Func, reflect.Chan:
+		return rv.IsNil()
+	}
+	return false
+}
+
+// detail value of the entry normalized for encoding.
+func (e *Entry) detail(v interface{}) interface{} {
+	return e.norm.value(v)
+}
+
+// normalizedDetails of the entry with fields added with With, for encoders that need a single map.
+func (e *Entry) normalizedDetails() Fields {
+	details := e.mergedDetails()
+	if len(details) == 0 {
+		return details
+	}
+	m := make(Fields, len(details))
+	for k, v := range details {
+		m[k] = e.norm.value(v)
+	}
+	return m
+}
+
+// SetDetailStringers writes detail values implementing fmt.Stringer with their String method.
+// Values that control their own encoding, such as by implementing json.Marshaler, are not affected.
+func (l *Logger) SetDetailStringers(enabled bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.norm.stringers = enabled
+}
+
+// SetDetailStringers writes detail values of the package-level logger implementing fmt.Stringer with their String method.
+func SetDetailStringers(enabled bool) {
+	std.SetDetailStringers(enabled)
+}
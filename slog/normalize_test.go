@@ -0,0 +1,139 @@
+package slog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+type version struct{ major, minor int }
+
+func (v version) String() string { return "v1.2" }
+
+type node struct {
+	Name string
+	Next *node `json:"next,omitempty"`
+}
+
+type Embedded struct {
+	Inner string
+}
+
+type tagged struct {
+	Embedded
+	Renamed  string        `json:"renamed"`
+	Omitted  string        `json:",omitempty"`
+	Skipped  string        `json:"-"`
+	Took     time.Duration `json:"took"`
+	internal string
+}
+
+type celsius float64
+
+// MarshalText with a pointer receiver, only used by encoding/json when the value is addressable.
+func (c *celsius) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("%.1fC", float64(*c))), nil
+}
+
+type reading struct {
+	Temp  celsius `json:"temp"`
+	Count int     `json:"count,string"`
+}
+
+type nilError struct{}
+
+func (*nilError) Error() string { return "not nil" }
+
+func TestNormalize(t *testing.T) {
+	when := time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC)
+	loop := &node{Name: "a"}
+	loop.Next = loop
+	shared := &node{Name: "shared"}
+	self := Fields{"name": "self"}
+	self["self"] = self
+	same := Fields{"a": 1}
+	list := []interface{}{"a", nil}
+	list[1] = list
+	var nilErr *nilError
+	tests := []struct {
+		name string
+		v    interface{}
+		want string
+	}{
+		{"string", "s", `"s"`},
+		{"int", 3, `3`},
+		{"duration", 1500 * time.Millisecond, `"1.5s"`},
+		{"time", when, `"2024-01-02T03:04:05.000000006Z"`},
+		{"error", errors.New("failed"), `"failed"`},
+		{"nil error", nilErr, `null`},
+		{"bytes", []byte("text"), `"text"`},
+		{"binary", []byte{0xff, 0xfe}, `"//4="`},
+		{"text marshaler", net.ParseIP("10.0.0.1"), `"10.0.0.1"`},
+		{"stringer", version{1, 2}, `{}`},
+		{"proto", &descriptorpb.FieldDescriptorProto{JsonName: proto.String("jsonName")}, `{"jsonName":"jsonName"}`},
+		{"well known proto", durationpb.New(1500 * time.Millisecond), `"1.500s"`},
+		{"map", map[string]interface{}{"took": time.Second, "nested": Fields{"err": errors.New("x")}}, `{"nested":{"err":"x"},"took":"1s"}`},
+		{"typed map", map[string]time.Duration{"took": time.Second}, `{"took":1000000000}`},
+		{"int keys", map[int]bool{1: true}, `{"1":true}`},
+		{"slice", []interface{}{time.Minute, errors.New("x")}, `["1m0s","x"]`},
+		{"struct", tagged{Embedded: Embedded{"in"}, Renamed: "r", Skipped: "s", Took: time.Second, internal: "i"}, `{"Inner":"in","renamed":"r","took":1000000000}`},
+		{"addressable", &reading{Temp: 21.5, Count: 3}, `{"temp":"21.5C","count":"3"}`},
+		{"not finite", struct{ F float64 }{math.NaN()}, `"\u003cunsupported struct { F float64 }\u003e"`},
+		{"cycle", loop, `"\u003ccycle\u003e"`},
+		{"fields cycle", self, `{"name":"self","self":"\u003ccycle\u003e"}`},
+		{"pointer cycle", &self, `{"name":"self","self":"\u003ccycle\u003e"}`},
+		{"slice cycle", list, `["a","\u003ccycle\u003e"]`},
+		{"shared", []*node{shared, shared}, `[{"Name":"shared"},{"Name":"shared"}]`},
+		{"shared fields", []interface{}{same, same, []interface{}{}, []interface{}{}}, `[{"a":1},{"a":1},[],[]]`},
+		{"channel", make(chan int), `"\u003cunsupported chan int\u003e"`},
+	}
+	for _, tt := range tests {
+		b, err := json.Marshal(normalizer{}.value(tt.v))
+		if err != nil {
+			t.Errorf("%s: marshaling: %v", tt.name, err)
+			continue
+		}
+		if string(b) != tt.want {
+			t.Errorf("%s: unexpected value\nwant: %s\ngot: %s", tt.name, tt.want, b)
+		}
+	}
+
+	if got := (normalizer{stringers: true}).value(version{1, 2}); got != "v1.2" {
+		t.Errorf("stringer not used when enabled: %v", got)
+	}
+	if got := (normalizer{stringers: true}).value(time.Second); got != "1s" {
+		t.Errorf("unexpected duration with stringers: %v", got)
+	}
+}
+
+func TestNormalizedDetails(t *testing.T) {
+	out := bytes.NewBuffer(make([]byte, 0, 1024))
+	logger := newLogger(out)
+	e := logger.entry().
+		WithDetail("field", &descriptorpb.FieldDescriptorProto{JsonName: proto.String("x")}).
+		WithDetail("version", version{1, 2}).
+		With(Duration("took", time.Second), Any("err", errors.New("failed")))
+
+	e.Info("normalized")
+	got := out.String()
+	out.Reset()
+	if !strings.Contains(got, `"details":{"field":{"jsonName":"x"},"version":{},"took":"1s","err":"failed"}`) {
+		t.Errorf("details not normalized: %s", got)
+	}
+
+	logger.SetDetailStringers(true)
+	e.Info("stringers")
+	if got := out.String(); !strings.Contains(got, `"version":"v1.2"`) {
+		t.Errorf("stringer not used: %s", got)
+	}
+}
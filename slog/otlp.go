@@ -0,0 +1,322 @@
+package slog
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// OTLPEndpoint of a local OpenTelemetry Collector receiving logs over HTTP.
+const OTLPEndpoint = "http://localhost:4318/v1/logs"
+
+// Protocols for OTLPConfig, given as the content type of requests.
+const (
+	OTLPProtobuf = "application/x-protobuf"
+	OTLPJSON     = "application/json"
+)
+
+// otlpScope is the instrumentation scope name for records sent by the sink.
+const otlpScope = "github.com/ParticleHealth/tau/slog"
+
+// OTLPConfig for a Sink exporting logs to an OpenTelemetry Collector over OTLP/HTTP.
+type OTLPConfig struct {
+	// Endpoint to post logs to, defaults to OTLPEndpoint.
+	Endpoint string
+	// Protocol of requests, either OTLPProtobuf or OTLPJSON. Defaults to OTLPProtobuf.
+	Protocol string
+	// Headers added to every request, such as for authentication.
+	Headers map[string]string
+	// ServiceName for the service.name resource attribute, defaults to the service label of Resource or else its job label.
+	ServiceName string
+	// Resource detected with DetectResource, mapped to OpenTelemetry resource attributes.
+	Resource *Resource
+	// ResourceAttributes added to or replacing those from Resource.
+	ResourceAttributes map[string]string
+	// Client used for requests, defaults to http.DefaultClient.
+	Client *http.Client
+	// BatchCount, BatchBytes and BatchDelay at which a batch of records is sent, whichever is reached first.
+	BatchCount int
+	BatchBytes int
+	BatchDelay time.Duration
+	// MaxRetries for transient failures, defaults to 3 and disabled if negative.
+	// The delay between attempts starts at Backoff and doubles each attempt.
+	MaxRetries int
+	Backoff    time.Duration
+	// QueueSize of entries waiting to be batched before further entries are dropped.
+	QueueSize int
+}
+
+// NewOTLPSink exporting entries as OTLP log records to an OpenTelemetry Collector.
+// Entries are queued and sent in batches so logging is never blocked on requests.
+// Close the sink before exiting to send any remaining entries.
+func NewOTLPSink(cfg OTLPConfig) (*Sink, error) {
+	w, err := newOTLPWriter(cfg)
+	if err != nil {
+		return nil, err
+	}
+	size := cfg.QueueSize
+	if size <= 0 {
+		size = defaultQueueSize
+	}
+	s := NewAsyncSink(w, size)
+	s.SetEncoder(OTLPEncoder)
+	return s, nil
+}
+
+// otlpWriter batches OTLP JSON log records, one per Write, and exports them to a collector.
+type otlpWriter struct {
+	*batchWriter
+	cfg      OTLPConfig
+	header   http.Header
+	resource []otlpAttribute
+}
+
+// newOTLPWriter with defaults applied to cfg.
+func newOTLPWriter(cfg OTLPConfig) (*otlpWriter, error) {
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = OTLPEndpoint
+	}
+	switch cfg.Protocol {
+	case "":
+		cfg.Protocol = OTLPProtobuf
+	case OTLPProtobuf, OTLPJSON:
+	default:
+		return nil, fmt.Errorf("unsupported otlp protocol %s", cfg.Protocol)
+	}
+	if cfg.Client == nil {
+		cfg.Client = http.DefaultClient
+	}
+	header := http.Header{"Content-Type": {cfg.Protocol}}
+	for k, v := range cfg.Headers {
+		header.Set(k, v)
+	}
+	w := &otlpWriter{cfg: cfg, header: header, resource: otlpResource(cfg)}
+	w.batchWriter = &batchWriter{
+		cfg:  newBatchConfig(cfg.BatchCount, cfg.BatchBytes, cfg.BatchDelay, cfg.MaxRetries, cfg.Backoff),
+		post: w.post,
+	}
+	return w, nil
+}
+
+// otlpResource attributes following the OpenTelemetry semantic conventions for GCP.
+func otlpResource(cfg OTLPConfig) []otlpAttribute {
+	attrs := make(map[string]interface{})
+	if r := cfg.Resource; r != nil {
+		attrs["cloud.provider"] = "gcp"
+		if r.Project != "" {
+			attrs["cloud.account.id"] = r.Project
+		}
+		for label, attr := range map[string]string{
+			LabelService:    "service.name",
+			LabelRevision:   "service.version",
+			LabelJob:        "faas.name",
+			LabelExecution:  "gcp.cloud_run.job.execution",
+			LabelTaskIndex:  "gcp.cloud_run.job.task_index",
+			LabelRegion:     "cloud.region",
+			LabelInstanceID: "service.instance.id",
+		} {
+			if v := r.Labels[label]; v != "" {
+				attrs[attr] = v
+			}
+		}
+		// The service takes precedence for service.name, a job only names it if there is no service.
+		if r.Labels[LabelService] == "" && r.Labels[LabelJob] != "" {
+			attrs["service.name"] = r.Labels[LabelJob]
+		}
+		if r.Labels[LabelService] != "" || r.Labels[LabelJob] != "" {
+			attrs["cloud.platform"] = "gcp_cloud_run"
+		}
+	}
+	if cfg.ServiceName != "" {
+		attrs["service.name"] = cfg.ServiceName
+	}
+	for k, v := range cfg.ResourceAttributes {
+		attrs[k] = v
+	}
+	return otlpAttributes(attrs)
+}
+
+// otlpRequest is an ExportLogsServiceRequest in the OTLP JSON encoding.
+type otlpRequest struct {
+	ResourceLogs []otlpResourceLogs `json:"resourceLogs"`
+}
+
+type otlpResourceLogs struct {
+	Resource  otlpResourceAttrs `json:"resource"`
+	ScopeLogs []otlpScopeLogs   `json:"scopeLogs"`
+}
+
+type otlpResourceAttrs struct {
+	Attributes []otlpAttribute `json:"attributes,omitempty"`
+}
+
+type otlpScopeLogs struct {
+	Scope      otlpInstrumentationScope `json:"scope"`
+	LogRecords []json.RawMessage        `json:"logRecords"`
+}
+
+type otlpInstrumentationScope struct {
+	Name string `json:"name"`
+}
+
+// post a batch to the collector once, reporting whether a failure is worth retrying.
+func (w *otlpWriter) post(batch []json.RawMessage) (bool, error) {
+	var body []byte
+	if w.cfg.Protocol == OTLPJSON {
+		var err error
+		body, err = json.Marshal(otlpRequest{ResourceLogs: []otlpResourceLogs{{
+			Resource:  otlpResourceAttrs{Attributes: w.resource},
+			ScopeLogs: []otlpScopeLogs{{Scope: otlpInstrumentationScope{Name: otlpScope}, LogRecords: batch}},
+		}}})
+		if err != nil {
+			return false, fmt.Errorf("marshaling records: %w", err)
+		}
+	} else {
+		records := make([]*otlpLogRecord, len(batch))
+		for i, raw := range batch {
+			records[i] = new(otlpLogRecord)
+			if err := json.Unmarshal(raw, records[i]); err != nil {
+				return false, fmt.Errorf("decoding record: %w", err)
+			}
+		}
+		body = marshalOTLPRequest(w.resource, records)
+	}
+	return postBody(w.cfg.Client, w.cfg.Endpoint, w.header, body)
+}
+
+// Protobuf wire types used by OTLP messages.
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+func appendUvarint(b []byte, v uint64) []byte {
+	var tmp [binary.MaxVarintLen64]byte
+	return append(b, tmp[:binary.PutUvarint(tmp[:], v)]...)
+}
+
+func appendTag(b []byte, field, wire int) []byte {
+	return appendUvarint(b, uint64(field<<3|wire))
+}
+
+func appendVarintField(b []byte, field int, v uint64) []byte {
+	return appendUvarint(appendTag(b, field, wireVarint), v)
+}
+
+func appendBytesField(b []byte, field int, v []byte) []byte {
+	b = appendUvarint(appendTag(b, field, wireBytes), uint64(len(v)))
+	return append(b, v...)
+}
+
+func appendStringField(b []byte, field int, v string) []byte {
+	b = appendUvarint(appendTag(b, field, wireBytes), uint64(len(v)))
+	return append(b, v...)
+}
+
+func appendFixed64Field(b []byte, field int, v uint64) []byte {
+	var tmp [8]byte
+	binary.LittleEndian.PutUint64(tmp[:], v)
+	return append(appendTag(b, field, wireFixed64), tmp[:]...)
+}
+
+func appendFixed32Field(b []byte, field int, v uint32) []byte {
+	var tmp [4]byte
+	binary.LittleEndian.PutUint32(tmp[:], v)
+	return append(appendTag(b, field, wireFixed32), tmp[:]...)
+}
+
+// marshalOTLPRequest as an ExportLogsServiceRequest protobuf.
+// See https://github.com/open-telemetry/opentelemetry-proto/blob/main/opentelemetry/proto/logs/v1/logs.proto for reference.
+func marshalOTLPRequest(resource []otlpAttribute, records []*otlpLogRecord) []byte {
+	var res []byte
+	for _, a := range resource {
+		res = appendBytesField(res, 1, marshalOTLPKeyValue(a))
+	}
+	scope := appendStringField(nil, 1, otlpScope)
+	var scopeLogs []byte
+	scopeLogs = appendBytesField(scopeLogs, 1, scope)
+	for _, r := range records {
+		scopeLogs = appendBytesField(scopeLogs, 2, marshalOTLPLogRecord(r))
+	}
+	var rl []byte
+	rl = appendBytesField(rl, 1, res)
+	rl = appendBytesField(rl, 2, scopeLogs)
+	return appendBytesField(nil, 1, rl)
+}
+
+// marshalOTLPLogRecord as a LogRecord protobuf.
+func marshalOTLPLogRecord(r *otlpLogRecord) []byte {
+	var b []byte
+	if ts, err := strconv.ParseUint(r.TimeUnixNano, 10, 64); err == nil && ts != 0 {
+		b = appendFixed64Field(b, 1, ts)
+	}
+	if r.SeverityNumber != 0 {
+		b = appendVarintField(b, 2, uint64(r.SeverityNumber))
+	}
+	if r.SeverityText != "" {
+		b = appendStringField(b, 3, r.SeverityText)
+	}
+	b = appendBytesField(b, 5, marshalOTLPValue(r.Body))
+	for _, a := range r.Attributes {
+		b = appendBytesField(b, 6, marshalOTLPKeyValue(a))
+	}
+	if r.Flags != 0 {
+		b = appendFixed32Field(b, 8, r.Flags)
+	}
+	if id, err := hex.DecodeString(r.TraceID); err == nil && len(id) == 16 {
+		b = appendBytesField(b, 9, id)
+	}
+	if id, err := hex.DecodeString(r.SpanID); err == nil && len(id) == 8 {
+		b = appendBytesField(b, 10, id)
+	}
+	if ts, err := strconv.ParseUint(r.ObservedTimeUnixNano, 10, 64); err == nil && ts != 0 {
+		b = appendFixed64Field(b, 11, ts)
+	}
+	return b
+}
+
+// marshalOTLPKeyValue as a KeyValue protobuf.
+func marshalOTLPKeyValue(a otlpAttribute) []byte {
+	b := appendStringField(nil, 1, a.Key)
+	return appendBytesField(b, 2, marshalOTLPValue(a.Value))
+}
+
+// marshalOTLPValue as an AnyValue protobuf, an empty message if no value is set.
+func marshalOTLPValue(v otlpValue) []byte {
+	var b []byte
+	switch {
+	case v.StringValue != nil:
+		b = appendStringField(b, 1, *v.StringValue)
+	case v.BoolValue != nil:
+		n := uint64(0)
+		if *v.BoolValue {
+			n = 1
+		}
+		b = appendVarintField(b, 2, n)
+	case v.IntValue != nil:
+		n, _ := strconv.ParseInt(*v.IntValue, 10, 64)
+		b = appendVarintField(b, 3, uint64(n))
+	case v.DoubleValue != nil:
+		b = appendFixed64Field(b, 4, math.Float64bits(*v.DoubleValue))
+	case v.ArrayValue != nil:
+		var arr []byte
+		for _, x := range v.ArrayValue.Values {
+			arr = appendBytesField(arr, 1, marshalOTLPValue(x))
+		}
+		b = appendBytesField(b, 5, arr)
+	case v.KvlistValue != nil:
+		var kvs []byte
+		for _, a := range v.KvlistValue.Values {
+			kvs = appendBytesField(kvs, 1, marshalOTLPKeyValue(a))
+		}
+		b = appendBytesField(b, 6, kvs)
+	}
+	return b
+}
@@ -0,0 +1,354 @@
+package slog
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// protoField decoded from the protobuf wire format.
+type protoField struct {
+	num   int
+	value uint64
+	bytes []byte
+}
+
+// decodeProto fields of a message, enough to read back OTLP requests.
+func decodeProto(b []byte) ([]protoField, error) {
+	var fields []protoField
+	for len(b) > 0 {
+		tag, n := binary.Uvarint(b)
+		if n <= 0 {
+			return nil, errors.New("invalid tag")
+		}
+		b = b[n:]
+		f := protoField{num: int(tag >> 3)}
+		switch tag & 7 {
+		case wireVarint:
+			f.value, n = binary.Uvarint(b)
+			if n <= 0 {
+				return nil, errors.New("invalid varint")
+			}
+			b = b[n:]
+		case wireFixed64:
+			f.value, b = binary.LittleEndian.Uint64(b), b[8:]
+		case wireFixed32:
+			f.value, b = uint64(binary.LittleEndian.Uint32(b)), b[4:]
+		case wireBytes:
+			l, n := binary.Uvarint(b)
+			if n <= 0 || int(l) > len(b[n:]) {
+				return nil, errors.New("invalid length")
+			}
+			f.bytes, b = b[n:n+int(l)], b[n+int(l):]
+		default:
+			return nil, errors.New("unexpected wire type")
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+func mustDecodeProto(t *testing.T, b []byte) []protoField {
+	t.Helper()
+	fields, err := decodeProto(b)
+	if err != nil {
+		t.Fatalf("decoding protobuf: %v", err)
+	}
+	return fields
+}
+
+func decodeOTLPValue(t *testing.T, b []byte) otlpValue {
+	var v otlpValue
+	for _, f := range mustDecodeProto(t, b) {
+		switch f.num {
+		case 1:
+			s := string(f.bytes)
+			v.StringValue = &s
+		case 2:
+			bv := f.value == 1
+			v.BoolValue = &bv
+		case 3:
+			s := strconv.FormatInt(int64(f.value), 10)
+			v.IntValue = &s
+		case 4:
+			d := math.Float64frombits(f.value)
+			v.DoubleValue = &d
+		case 5:
+			v.ArrayValue = &otlpArray{Values: []otlpValue{}}
+			for _, x := range mustDecodeProto(t, f.bytes) {
+				v.ArrayValue.Values = append(v.ArrayValue.Values, decodeOTLPValue(t, x.bytes))
+			}
+		case 6:
+			v.KvlistValue = &otlpArrayOfKVs{}
+			for _, x := range mustDecodeProto(t, f.bytes) {
+				v.KvlistValue.Values = append(v.KvlistValue.Values, decodeOTLPKeyValue(t, x.bytes))
+			}
+		}
+	}
+	return v
+}
+
+func decodeOTLPKeyValue(t *testing.T, b []byte) otlpAttribute {
+	var a otlpAttribute
+	for _, f := range mustDecodeProto(t, b) {
+		switch f.num {
+		case 1:
+			a.Key = string(f.bytes)
+		case 2:
+			a.Value = decodeOTLPValue(t, f.bytes)
+		}
+	}
+	return a
+}
+
+func decodeOTLPLogRecord(t *testing.T, b []byte) otlpLogRecord {
+	var r otlpLogRecord
+	for _, f := range mustDecodeProto(t, b) {
+		switch f.num {
+		case 1:
+			r.TimeUnixNano = strconv.FormatUint(f.value, 10)
+		case 2:
+			r.SeverityNumber = int(f.value)
+		case 3:
+			r.SeverityText = string(f.bytes)
+		case 5:
+			r.Body = decodeOTLPValue(t, f.bytes)
+		case 6:
+			r.Attributes = append(r.Attributes, decodeOTLPKeyValue(t, f.bytes))
+		case 8:
+			r.Flags = uint32(f.value)
+		case 9:
+			r.TraceID = hex.EncodeToString(f.bytes)
+		case 10:
+			r.SpanID = hex.EncodeToString(f.bytes)
+		case 11:
+			r.ObservedTimeUnixNano = strconv.FormatUint(f.value, 10)
+		}
+	}
+	return r
+}
+
+// otlpExport received by the fake collector, decoded from either protocol.
+type otlpExport struct {
+	contentType string
+	header      http.Header
+	scope       string
+	resource    []otlpAttribute
+	records     []otlpLogRecord
+}
+
+// decodeOTLPProto request into an export.
+func decodeOTLPProto(t *testing.T, b []byte) otlpExport {
+	var ex otlpExport
+	for _, rl := range mustDecodeProto(t, b) {
+		for _, f := range mustDecodeProto(t, rl.bytes) {
+			switch f.num {
+			case 1:
+				for _, a := range mustDecodeProto(t, f.bytes) {
+					ex.resource = append(ex.resource, decodeOTLPKeyValue(t, a.bytes))
+				}
+			case 2:
+				for _, sl := range mustDecodeProto(t, f.bytes) {
+					switch sl.num {
+					case 1:
+						ex.scope = string(mustDecodeProto(t, sl.bytes)[0].bytes)
+					case 2:
+						ex.records = append(ex.records, decodeOTLPLogRecord(t, sl.bytes))
+					}
+				}
+			}
+		}
+	}
+	return ex
+}
+
+// decodeOTLPJSON request into an export.
+func decodeOTLPJSON(t *testing.T, b []byte) otlpExport {
+	var req otlpRequest
+	if err := json.Unmarshal(b, &req); err != nil {
+		t.Fatalf("decoding request: %v", err)
+	}
+	var ex otlpExport
+	for _, rl := range req.ResourceLogs {
+		ex.resource = append(ex.resource, rl.Resource.Attributes...)
+		for _, sl := range rl.ScopeLogs {
+			ex.scope = sl.Scope.Name
+			for _, raw := range sl.LogRecords {
+				var r otlpLogRecord
+				if err := json.Unmarshal(raw, &r); err != nil {
+					t.Fatalf("decoding record: %v", err)
+				}
+				ex.records = append(ex.records, r)
+			}
+		}
+	}
+	return ex
+}
+
+// fakeCollector records OTLP/HTTP log exports, failing the first failures requests.
+type fakeCollector struct {
+	*httptest.Server
+	mu       sync.Mutex
+	exports  []otlpExport
+	attempts int
+	failures int
+}
+
+func newFakeCollector(t *testing.T, failures int) *fakeCollector {
+	t.Helper()
+	f := &fakeCollector{failures: failures}
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.attempts++
+		if f.attempts <= f.failures {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil || r.URL.Path != "/v1/logs" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var ex otlpExport
+		switch ct := r.Header.Get("Content-Type"); ct {
+		case OTLPProtobuf:
+			ex = decodeOTLPProto(t, body)
+		case OTLPJSON:
+			ex = decodeOTLPJSON(t, body)
+		default:
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			return
+		}
+		ex.contentType = r.Header.Get("Content-Type")
+		ex.header = r.Header
+		f.exports = append(f.exports, ex)
+	}))
+	t.Cleanup(f.Close)
+	return f
+}
+
+func TestOTLPSink(t *testing.T) {
+	for _, protocol := range []string{OTLPProtobuf, OTLPJSON} {
+		t.Run(protocol, func(t *testing.T) {
+			srv := newFakeCollector(t, 1)
+			sink, err := NewOTLPSink(OTLPConfig{
+				Endpoint: srv.URL + "/v1/logs",
+				Protocol: protocol,
+				Headers:  map[string]string{"Authorization": "Bearer token"},
+				Resource: &Resource{
+					Project: "test-project",
+					Labels:  map[string]string{LabelService: "records", LabelRevision: "records-001", LabelRegion: "us-central1"},
+				},
+				ResourceAttributes: map[string]string{"deployment.environment": "test"},
+				BatchCount:         2,
+				BatchDelay:         time.Hour,
+				Backoff:            time.Millisecond,
+			})
+			if err != nil {
+				t.Fatalf("creating sink: %v", err)
+			}
+			logger := New(sink)
+			logger.SetIncludeSources(false)
+			e := logger.entry()
+			e.Trace = "projects/test-project/traces/4bf92f3577b34da6a3ce929d0e0e4736"
+			e.SpanID = "00f067aa0ba902b7"
+			e.TraceSampled = true
+			e.WithLabels(Fields{"team": "a"}).With(Int("count", -3), Bool("ok", true), Float64("ratio", 0.5), Any("tags", []string{"x"})).Warn("first")
+			logger.Info("second")
+			logger.Notice("third")
+			if err := sink.Close(); err != nil {
+				t.Fatalf("closing sink: %v", err)
+			}
+
+			srv.mu.Lock()
+			defer srv.mu.Unlock()
+			if srv.attempts != 3 || len(srv.exports) != 2 {
+				t.Fatalf("unexpected requests\nattempts: %d\nexports: %d", srv.attempts, len(srv.exports))
+			}
+			ex := srv.exports[0]
+			if ex.contentType != protocol || ex.header.Get("Authorization") != "Bearer token" || ex.scope != otlpScope {
+				t.Errorf("unexpected request: %s %v %s", ex.contentType, ex.header, ex.scope)
+			}
+			wantResource := otlpAttributes(map[string]interface{}{
+				"cloud.provider":         "gcp",
+				"cloud.platform":         "gcp_cloud_run",
+				"cloud.account.id":       "test-project",
+				"cloud.region":           "us-central1",
+				"service.name":           "records",
+				"service.version":        "records-001",
+				"deployment.environment": "test",
+			})
+			if diff := cmp.Diff(wantResource, ex.resource); diff != "" {
+				t.Errorf("unexpected resource:\n%s", diff)
+			}
+
+			first := ex.records[0]
+			if first.SeverityNumber != 13 || first.SeverityText != "WARNING" || *first.Body.StringValue != "first" {
+				t.Errorf("unexpected record: %+v", first)
+			}
+			if first.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || first.SpanID != "00f067aa0ba902b7" || first.Flags != 1 {
+				t.Errorf("unexpected trace: %s %s %d", first.TraceID, first.SpanID, first.Flags)
+			}
+			if first.TimeUnixNano == "" || first.TimeUnixNano == "0" {
+				t.Errorf("missing time: %+v", first)
+			}
+			wantAttrs := otlpAttributes(map[string]interface{}{
+				"team": "a", "count": int64(-3), "ok": true, "ratio": 0.5, "tags": []string{"x"},
+			})
+			if diff := cmp.Diff(wantAttrs, first.Attributes); diff != "" {
+				t.Errorf("unexpected attributes:\n%s", diff)
+			}
+			if got := srv.exports[1].records; len(got) != 1 || got[0].SeverityNumber != 10 {
+				t.Errorf("unexpected final batch: %+v", got)
+			}
+		})
+	}
+}
+
+func TestOTLPConfig(t *testing.T) {
+	if _, err := NewOTLPSink(OTLPConfig{Protocol: "grpc"}); err == nil {
+		t.Error("expected error for unsupported protocol")
+	}
+	w, err := newOTLPWriter(OTLPConfig{})
+	if err != nil {
+		t.Fatalf("creating writer: %v", err)
+	}
+	if w.cfg.Endpoint != OTLPEndpoint || w.header.Get("Content-Type") != OTLPProtobuf {
+		t.Errorf("defaults not applied: %+v", w.cfg)
+	}
+}
+
+func TestOTLPResourceServiceName(t *testing.T) {
+	tests := []struct {
+		name   string
+		labels map[string]string
+		want   map[string]interface{}
+	}{
+		{"service", map[string]string{LabelService: "api"}, map[string]interface{}{"service.name": "api"}},
+		{"job", map[string]string{LabelJob: "batch"}, map[string]interface{}{"service.name": "batch", "faas.name": "batch"}},
+		{"both", map[string]string{LabelService: "api", LabelJob: "batch"}, map[string]interface{}{"service.name": "api", "faas.name": "batch"}},
+	}
+	for _, tt := range tests {
+		got := make(map[string]interface{})
+		for _, a := range otlpResource(OTLPConfig{Resource: &Resource{Labels: tt.labels}}) {
+			if a.Key == "service.name" || a.Key == "faas.name" {
+				got[a.Key] = *a.Value.StringValue
+			}
+		}
+		if diff := cmp.Diff(tt.want, got); diff != "" {
+			t.Errorf("%s: unexpected resource:\n%s", tt.name, diff)
+		}
+	}
+}
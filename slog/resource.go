@@ -0,0 +1,154 @@
+package slog
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Environment variables set by Cloud Run services and jobs.
+// See https://cloud.google.com/run/docs/container-contract#env-vars for reference.
+const (
+	envService       = "K_SERVICE"
+	envRevision      = "K_REVISION"
+	envConfiguration = "K_CONFIGURATION"
+	envJob           = "CLOUD_RUN_JOB"
+	envExecution     = "CLOUD_RUN_EXECUTION"
+	envTaskIndex     = "CLOUD_RUN_TASK_INDEX"
+	envMetadataHost  = "GCE_METADATA_HOST"
+)
+
+// defaultMetadataHost used when GCE_METADATA_HOST is not set.
+const defaultMetadataHost = "169.254.169.254"
+
+// Labels populated by DetectResource.
+const (
+	LabelService       = "service"
+	LabelRevision      = "revision"
+	LabelConfiguration = "configuration"
+	LabelJob           = "job"
+	LabelExecution     = "execution"
+	LabelTaskIndex     = "task_index"
+	LabelRegion        = "region"
+	LabelInstanceID    = "instance_id"
+)
+
+var metadataClient = &http.Client{Timeout: 2 * time.Second}
+
+// Resource the logger is running on, used to populate default labels and project.
+type Resource struct {
+	Project string
+	Labels  map[string]string
+}
+
+// DetectResource from Cloud Run environment variables.
+// If metadata is true the metadata server is also queried for project, region and instance ID,
+// using GCE_METADATA_HOST if set. Anything detected before an error is still returned.
+func DetectResource(ctx context.Context, metadata bool) (*Resource, error) {
+	r := &Resource{Labels: make(map[string]string)}
+	for env, label := range map[string]string{
+		envService:       LabelService,
+		envRevision:      LabelRevision,
+		envConfiguration: LabelConfiguration,
+		envJob:           LabelJob,
+		envExecution:     LabelExecution,
+		envTaskIndex:     LabelTaskIndex,
+	} {
+		if v := os.Getenv(env); v != "" {
+			r.Labels[label] = v
+		}
+	}
+	if !metadata {
+		return r, nil
+	}
+
+	project, err := getMetadata(ctx, "project/project-id")
+	if err != nil {
+		return r, err
+	}
+	r.Project = project
+	region, err := getMetadata(ctx, "instance/region")
+	if err != nil {
+		return r, err
+	}
+	// Region is returned as projects/<number>/regions/<region>.
+	r.Labels[LabelRegion] = region[strings.LastIndex(region, "/")+1:]
+	id, err := getMetadata(ctx, "instance/id")
+	if err != nil {
+		return r, err
+	}
+	r.Labels[LabelInstanceID] = id
+	return r, nil
+}
+
+// getMetadata value for a given path from the metadata server.
+func getMetadata(ctx context.Context, path string) (string, error) {
+	host := os.Getenv(envMetadataHost)
+	if host == "" {
+		host = defaultMetadataHost
+	}
+	url := fmt.Sprint("http://", host, "/computeMetadata/v1/", path)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", fmt.Errorf("creating metadata request for %s: %w", path, err)
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	resp, err := metadataClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("requesting metadata %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("requesting metadata %s: unexpected status %s", path, resp.Status)
+	}
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("reading metadata %s: %w", path, err)
+	}
+	return strings.TrimSpace(string(b)), nil
+}
+
+// SetResource for the logger. Sets the project and adds the resource labels to the default labels.
+func (l *Logger) SetResource(r *Resource) {
+	if r == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if r.Project != "" {
+		l.project = r.Project
+	}
+	if len(r.Labels) > 0 {
+		l.labels = mergeLabels(l.labels, r.Labels)
+	}
+}
+
+// SetResource for the package-level logger. Sets the project and adds the resource labels to the default labels.
+func SetResource(r *Resource) {
+	std.SetResource(r)
+}
+
+// SetLabels included by default in every log written by the logger.
+// Labels set on an Entry take precedence.
+func (l *Logger) SetLabels(labels Fields) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(labels) == 0 {
+		l.labels = nil
+		return
+	}
+	l.labels = make(map[string]string, len(labels))
+	for k, v := range labels {
+		l.labels[k] = fmt.Sprint(v)
+	}
+}
+
+// SetLabels included by default in every log written by the package-level logger.
+// Labels set on an Entry take precedence.
+func SetLabels(labels Fields) {
+	std.SetLabels(labels)
+}
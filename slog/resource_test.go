@@ -0,0 +1,116 @@
+package slog
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDetectResourceEnvironment(t *testing.T) {
+	t.Setenv(envService, "svc")
+	t.Setenv(envRevision, "svc-00001-abc")
+	t.Setenv(envConfiguration, "svc")
+	t.Setenv(envJob, "")
+	r, err := DetectResource(context.Background(), false)
+	if err != nil {
+		t.Fatalf("detecting resource: %v", err)
+	}
+	want := &Resource{Labels: map[string]string{
+		LabelService:       "svc",
+		LabelRevision:      "svc-00001-abc",
+		LabelConfiguration: "svc",
+	}}
+	if diff := cmp.Diff(want, r); diff != "" {
+		t.Errorf("unexpected resource:\n%s", diff)
+	}
+}
+
+func TestDetectResourceMetadata(t *testing.T) {
+	values := map[string]string{
+		"/computeMetadata/v1/project/project-id": "test-project",
+		"/computeMetadata/v1/instance/region":    "projects/123/regions/us-central1",
+		"/computeMetadata/v1/instance/id":        "instance-1",
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Metadata-Flavor") != "Google" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		v, ok := values[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(v))
+	}))
+	defer srv.Close()
+	t.Setenv(envMetadataHost, strings.TrimPrefix(srv.URL, "http://"))
+	t.Setenv(envJob, "batch")
+	t.Setenv(envTaskIndex, "3")
+
+	r, err := DetectResource(context.Background(), true)
+	if err != nil {
+		t.Fatalf("detecting resource: %v", err)
+	}
+	want := &Resource{Project: "test-project", Labels: map[string]string{
+		LabelJob:        "batch",
+		LabelTaskIndex:  "3",
+		LabelRegion:     "us-central1",
+		LabelInstanceID: "instance-1",
+	}}
+	if diff := cmp.Diff(want, r); diff != "" {
+		t.Errorf("unexpected resource:\n%s", diff)
+	}
+
+	delete(values, "/computeMetadata/v1/instance/id")
+	r, err = DetectResource(context.Background(), true)
+	if err == nil {
+		t.Error("expected error for missing metadata")
+	}
+	if r.Project != "test-project" {
+		t.Errorf("partial resource not returned\nwant: test-project\ngot: %v", r.Project)
+	}
+}
+
+func TestResourceLabels(t *testing.T) {
+	buf := bytes.NewBuffer(make([]byte, 0, 1024))
+	logger := newLogger(buf)
+	logger.SetResource(&Resource{Project: "test-project", Labels: map[string]string{LabelService: "svc"}})
+	logger.SetResource(nil)
+	if logger.project != "test-project" {
+		t.Errorf("project not set\nwant: test-project\ngot: %v", logger.project)
+	}
+	e := logger.WithLabels(Fields{"hello": "world"})
+	e.Info("testing")
+	got := buf.String()
+	buf.Reset()
+	if !strings.Contains(got, `"service":"svc"`) {
+		t.Errorf("resource label not included\ngot: %v", got)
+	}
+	if !strings.Contains(got, `"hello":"world"`) {
+		t.Errorf("entry label not included\ngot: %v", got)
+	}
+	if len(e.Labels) != 1 {
+		t.Errorf("default labels leaked into entry: %v", e.Labels)
+	}
+
+	logger.WithLabels(Fields{LabelService: "override"}).Info("testing")
+	got = buf.String()
+	buf.Reset()
+	if !strings.Contains(got, `"service":"override"`) {
+		t.Errorf("entry label did not take precedence\ngot: %v", got)
+	}
+
+	logger.SetLabels(nil)
+	logger.Info("testing")
+	got = buf.String()
+	buf.Reset()
+	if strings.Contains(got, "logging.googleapis.com/labels") {
+		t.Errorf("labels persist when they shouldn't\ngot: %v", got)
+	}
+}
@@ -0,0 +1,98 @@
+package slog
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Severity of an entry as specified in https://cloud.google.com/logging/docs/reference/v2/rest/v2/LogEntry#LogSeverity.
+// Values match the LogSeverity enum so severities can be compared directly.
+type Severity int
+
+// Severity levels in increasing order.
+const (
+	SeverityDefault   Severity = 0
+	SeverityDebug     Severity = 100
+	SeverityInfo      Severity = 200
+	SeverityNotice    Severity = 300
+	SeverityWarning   Severity = 400
+	SeverityError     Severity = 500
+	SeverityCritical  Severity = 600
+	SeverityAlert     Severity = 700
+	SeverityEmergency Severity = 800
+)
+
+var severityNames = map[Severity]string{
+	SeverityDefault:   "DEFAULT",
+	SeverityDebug:     "DEBUG",
+	SeverityInfo:      "INFO",
+	SeverityNotice:    "NOTICE",
+	SeverityWarning:   "WARNING",
+	SeverityError:     "ERROR",
+	SeverityCritical:  "CRITICAL",
+	SeverityAlert:     "ALERT",
+	SeverityEmergency: "EMERGENCY",
+}
+
+// String name of the severity as Cloud Logging expects it, or its number when it is not a known level.
+func (s Severity) String() string {
+	if name, ok := severityNames[s]; ok {
+		return name
+	}
+	return strconv.Itoa(int(s))
+}
+
+// ParseSeverity from a name such as "warning" or "WARN", or a number such as "400".
+func ParseSeverity(v string) (Severity, error) {
+	v = strings.ToUpper(strings.TrimSpace(v))
+	if v == "WARN" {
+		return SeverityWarning, nil
+	}
+	for s, name := range severityNames {
+		if name == v {
+			return s, nil
+		}
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return SeverityDefault, fmt.Errorf("unknown severity %q", v)
+	}
+	if _, ok := severityNames[Severity(n)]; !ok {
+		return SeverityDefault, fmt.Errorf("unknown severity %d", n)
+	}
+	return Severity(n), nil
+}
+
+// MarshalText of the severity name, so it is encoded in JSON as a string.
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText of a severity name or number.
+func (s *Severity) UnmarshalText(text []byte) error {
+	v, err := ParseSeverity(string(text))
+	if err != nil {
+		return err
+	}
+	*s = v
+	return nil
+}
+
+// UnmarshalJSON of a severity name or LogSeverity number.
+func (s *Severity) UnmarshalJSON(b []byte) error {
+	if len(b) > 0 && b[0] == '"' {
+		var v string
+		if err := json.Unmarshal(b, &v); err != nil {
+			return err
+		}
+		return s.UnmarshalText([]byte(v))
+	}
+	return s.UnmarshalText(b)
+}
+
+// Set the severity from a flag value, implementing flag.Value.
+func (s *Severity) Set(v string) error {
+	return s.UnmarshalText([]byte(v))
+}
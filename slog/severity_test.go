@@ -0,0 +1,115 @@
+package slog
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"testing"
+)
+
+func TestSeverityOrder(t *testing.T) {
+	order := []Severity{
+		SeverityDefault, SeverityDebug, SeverityInfo, SeverityNotice, SeverityWarning,
+		SeverityError, SeverityCritical, SeverityAlert, SeverityEmergency,
+	}
+	for i := 1; i < len(order); i++ {
+		if order[i-1] >= order[i] {
+			t.Errorf("%s not ordered below %s", order[i-1], order[i])
+		}
+	}
+	if SeverityWarning != 400 || SeverityEmergency != 800 {
+		t.Error("severities do not match LogSeverity values")
+	}
+}
+
+func TestParseSeverity(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Severity
+		wantErr bool
+	}{
+		{"WARNING", SeverityWarning, false},
+		{"warning", SeverityWarning, false},
+		{" Warn ", SeverityWarning, false},
+		{"default", SeverityDefault, false},
+		{"emergency", SeverityEmergency, false},
+		{"500", SeverityError, false},
+		{"0", SeverityDefault, false},
+		{"450", SeverityDefault, true},
+		{"loud", SeverityDefault, true},
+		{"", SeverityDefault, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseSeverity(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("unexpected error parsing %q: %v", tt.in, err)
+		}
+		if got != tt.want {
+			t.Errorf("unexpected severity parsing %q\nwant: %s\ngot: %s", tt.in, tt.want, got)
+		}
+	}
+	if got := Severity(450).String(); got != "450" {
+		t.Errorf("unexpected string for unknown severity: %s", got)
+	}
+}
+
+func TestSeverityEncoding(t *testing.T) {
+	var cfg struct {
+		Level Severity `json:"level"`
+		Min   Severity `json:"min"`
+	}
+	if err := json.Unmarshal([]byte(`{"level":"notice","min":600}`), &cfg); err != nil {
+		t.Fatalf("decoding config: %v", err)
+	}
+	if cfg.Level != SeverityNotice || cfg.Min != SeverityCritical {
+		t.Errorf("unexpected config: %+v", cfg)
+	}
+	b, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatalf("encoding config: %v", err)
+	}
+	if got, want := string(b), `{"level":"NOTICE","min":"CRITICAL"}`; got != want {
+		t.Errorf("unexpected encoding\nwant: %s\ngot: %s", want, got)
+	}
+	if err := json.Unmarshal([]byte(`{"level":"loud"}`), &cfg); err == nil {
+		t.Error("expected error for unknown severity")
+	}
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	level := SeverityInfo
+	fs.Var(&level, "level", "minimum severity")
+	if err := fs.Parse([]string{"-level", "error"}); err != nil {
+		t.Fatalf("parsing flags: %v", err)
+	}
+	if level != SeverityError {
+		t.Errorf("unexpected flag value: %s", level)
+	}
+}
+
+func TestLog(t *testing.T) {
+	out := bytes.NewBuffer(make([]byte, 0, 1024))
+	logger := newLogger(out)
+	logger.SetIncludeSources(true)
+	level, err := ParseSeverity("alert")
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.entry().Log(level, "generic")
+	var e Entry
+	if err := json.Unmarshal(out.Bytes(), &e); err != nil {
+		t.Fatalf("decoding entry: %v\n%s", err, out)
+	}
+	if e.Severity != SeverityAlert || e.SourceLocation.Function != "github.com/ParticleHealth/tau/slog.TestLog" {
+		t.Errorf("unexpected entry: %+v", e)
+	}
+	if e.StackTrace == "" {
+		t.Error("stack missing above Error")
+	}
+	if logger.Counts().Severity["ALERT"] != 1 {
+		t.Errorf("unexpected counts: %+v", logger.Counts())
+	}
+	logger.entry().Log(Severity(900), "out of range")
+	if logger.Counts().Severity["DEFAULT"] != 1 {
+		t.Errorf("unknown severity not counted as DEFAULT: %+v", logger.Counts())
+	}
+}
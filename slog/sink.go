@@ -0,0 +1,314 @@
+package slog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+// maxPooledBuffer size kept for reuse, larger buffers are left to the garbage collector.
+const maxPooledBuffer = 64 * 1024
+
+var bufPool = sync.Pool{New: func() interface{} { return new(bytes.Buffer) }}
+
+// Encoder serializes an Entry for a Sink.
+type Encoder interface {
+	// Encode e onto the end of buf, including any trailing delimiter.
+	Encode(buf *bytes.Buffer, e *Entry) error
+}
+
+// EncoderFunc allows a function to be used as an Encoder.
+type EncoderFunc func(buf *bytes.Buffer, e *Entry) error
+
+// Encode e onto the end of buf by calling f.
+func (f EncoderFunc) Encode(buf *bytes.Buffer, e *Entry) error {
+	return f(buf, e)
+}
+
+// JSONEncoder writes newline delimited JSON as expected by Cloud Logging structured logs.
+// Typed fields are written with details without being merged into a map.
+var JSONEncoder Encoder = EncoderFunc(func(buf *bytes.Buffer, e *Entry) error {
+	if len(e.fields) > 0 || len(e.Details) > 0 {
+		return json.NewEncoder(buf).Encode(newTypedEntry(e))
+	}
+	return json.NewEncoder(buf).Encode(e)
+})
+
+// flusher is implemented by writers that buffer output.
+type flusher interface {
+	Flush() error
+}
+
+// sinkWrite queued for an asynchronous Sink. A non-nil done is a flush marker.
+type sinkWrite struct {
+	b    []byte
+	done chan struct{}
+}
+
+// Sink is a destination for logs written by a Logger.
+// Each Sink has its own severity range, encoder and optional filter.
+type Sink struct {
+	mu      sync.RWMutex // guards configuration and the queue
+	min     Severity
+	max     Severity
+	encoder Encoder
+	filter  func(e *Entry) bool
+	closed  bool
+	queue   chan sinkWrite
+	stopped chan struct{}
+
+	wmu     sync.Mutex // ensures atomic writes
+	w       io.Writer
+	owned   bool // w was created for the sink and is closed with it
+	dropped uint64
+}
+
+// NewSink writing JSON to w synchronously.
+func NewSink(w io.Writer) *Sink {
+	return &Sink{w: w, encoder: JSONEncoder}
+}
+
+// NewAsyncSink writing JSON to w from a separate goroutine so a slow writer does not block logging.
+// Up to size encoded entries are buffered, any more are dropped and counted until there is room.
+func NewAsyncSink(w io.Writer, size int) *Sink {
+	s := NewSink(w)
+	s.async(size)
+	return s
+}
+
+// async writes of the sink from a separate goroutine.
+func (s *Sink) async(size int) {
+	s.queue = make(chan sinkWrite, size)
+	s.stopped = make(chan struct{})
+	go s.run()
+}
+
+// SetMinSeverity written to the sink, such as SeverityWarning. Lower severities are ignored.
+func (s *Sink) SetMinSeverity(min Severity) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.min = min
+}
+
+// SetMaxSeverity written to the sink, such as SeverityNotice. Higher severities are ignored.
+func (s *Sink) SetMaxSeverity(max Severity) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.max = max
+}
+
+// SetEncoder used to serialize entries for the sink.
+func (s *Sink) SetEncoder(enc Encoder) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.encoder = enc
+}
+
+// SetFilter for the sink. Only entries for which f returns true are written.
+func (s *Sink) SetFilter(f func(e *Entry) bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.filter = f
+}
+
+// dropper is implemented by writers that drop entries, such as those sending batches.
+type dropper interface {
+	Dropped() uint64
+}
+
+// Dropped returns the number of entries an asynchronous sink has dropped because its buffer was full,
+// including those its writer dropped such as batches that could not be sent.
+func (s *Sink) Dropped() uint64 {
+	n := atomic.LoadUint64(&s.dropped)
+	if d, ok := s.w.(dropper); ok {
+		n += d.Dropped()
+	}
+	return n
+}
+
+// accepts an entry based on severity and filter. Must be called with mu held.
+func (s *Sink) accepts(e *Entry) bool {
+	r := e.Severity
+	if r < s.min {
+		return false
+	}
+	if s.max != 0 && r > s.max {
+		return false
+	}
+	return s.filter == nil || s.filter(e)
+}
+
+// write an entry to the sink if it is accepted.
+func (s *Sink) write(e *Entry) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed || !s.accepts(e) {
+		return
+	}
+
+	buf := bufPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer func() {
+		if buf.Cap() <= maxPooledBuffer {
+			bufPool.Put(buf)
+		}
+	}()
+	if err := s.encoder.Encode(buf, e); err != nil {
+		fmt.Fprintln(os.Stderr, "could not marshal log:", err)
+		return
+	}
+
+	if s.queue == nil {
+		s.writeBytes(buf.Bytes())
+		return
+	}
+	b := make([]byte, buf.Len())
+	copy(b, buf.Bytes())
+	select {
+	case s.queue <- sinkWrite{b: b}:
+	default:
+		atomic.AddUint64(&s.dropped, 1)
+	}
+}
+
+// writeBytes to the underlying writer.
+func (s *Sink) writeBytes(b []byte) {
+	s.wmu.Lock()
+	defer s.wmu.Unlock()
+	if _, err := s.w.Write(b); err != nil {
+		fmt.Fprintln(os.Stderr, "could not write log:", err)
+	}
+}
+
+// run writes queued entries until the queue is closed.
+func (s *Sink) run() {
+	defer close(s.stopped)
+	for w := range s.queue {
+		if w.done != nil {
+			close(w.done)
+			continue
+		}
+		s.writeBytes(w.b)
+	}
+}
+
+// Flush waits for queued entries to be written and flushes the writer if it supports it.
+func (s *Sink) Flush() error {
+	s.mu.RLock()
+	if s.queue != nil && !s.closed {
+		done := make(chan struct{})
+		s.queue <- sinkWrite{done: done}
+		s.mu.RUnlock()
+		<-done
+	} else {
+		s.mu.RUnlock()
+	}
+	return s.flushWriter()
+}
+
+// flushWriter if it buffers output.
+func (s *Sink) flushWriter() error {
+	f, ok := s.w.(flusher)
+	if !ok {
+		return nil
+	}
+	s.wmu.Lock()
+	defer s.wmu.Unlock()
+	return f.Flush()
+}
+
+// Close the sink, writing anything queued. Further entries are ignored.
+// The underlying writer is flushed, and closed only if the sink created it such as for syslog.
+func (s *Sink) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	if s.queue != nil {
+		close(s.queue)
+	}
+	s.mu.Unlock()
+	if s.stopped != nil {
+		<-s.stopped
+	}
+	err := s.flushWriter()
+	if c, ok := s.w.(io.Closer); ok && s.owned {
+		s.wmu.Lock()
+		defer s.wmu.Unlock()
+		if cerr := c.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// New Logger writing to the given sinks in order.
+// Synchronous sinks are written before logging returns, so a slow one delays the others.
+// Use NewAsyncSink for writers that may block, such as network connections.
+func New(sinks ...*Sink) *Logger {
+	return &Logger{sinks: sinks, sources: true}
+}
+
+// SetSinks the logger writes to, replacing any existing sinks.
+// Replaced sinks are flushed but not closed, as they may be shared with other loggers. Close them once unused
+// so asynchronous sinks stop their goroutine.
+func (l *Logger) SetSinks(sinks ...*Sink) {
+	l.mu.Lock()
+	old := l.sinks
+	l.sinks = sinks
+	l.mu.Unlock()
+	for _, s := range old {
+		if err := s.Flush(); err != nil {
+			fmt.Fprintln(os.Stderr, "could not flush log:", err)
+		}
+	}
+}
+
+// SetSinks the package-level logger writes to, replacing any existing sinks.
+func SetSinks(sinks ...*Sink) {
+	std.SetSinks(sinks...)
+}
+
+// AddSink for the logger to write to in addition to existing sinks.
+func (l *Logger) AddSink(s *Sink) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sinks = append(l.sinks[:len(l.sinks):len(l.sinks)], s)
+}
+
+// AddSink for the package-level logger to write to in addition to existing sinks.
+func AddSink(s *Sink) {
+	std.AddSink(s)
+}
+
+// Flush all sinks of the logger, returning the first error encountered.
+// Summaries of de-duplicated entries are written first.
+func (l *Logger) Flush() error {
+	l.mu.Lock()
+	d := l.dedup
+	l.mu.Unlock()
+	if d != nil {
+		l.flushDedup(d)
+	}
+	l.mu.Lock()
+	sinks := l.sinks
+	l.mu.Unlock()
+	var first error
+	for _, s := range sinks {
+		if err := s.Flush(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// Flush all sinks of the package-level logger, returning the first error encountered.
+func Flush() error {
+	return std.Flush()
+}
@@ -0,0 +1,173 @@
+package slog
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// blockingWriter blocks all writes until released.
+type blockingWriter struct {
+	release chan struct{}
+	mu      sync.Mutex
+	buf     bytes.Buffer
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	<-w.release
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func (w *blockingWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.String()
+}
+
+func TestSinkRouting(t *testing.T) {
+	stdout := bytes.NewBuffer(make([]byte, 0, 1024))
+	stderr := bytes.NewBuffer(make([]byte, 0, 1024))
+	out := NewSink(stdout)
+	out.SetMaxSeverity(SeverityWarning)
+	errs := NewSink(stderr)
+	errs.SetMinSeverity(SeverityError)
+	logger := New(out, errs)
+
+	logger.Info("info")
+	logger.Warn("warning")
+	logger.Error("error")
+	logger.Emergency("emergency")
+	if err := logger.Flush(); err != nil {
+		t.Fatalf("flushing: %v", err)
+	}
+	if got := strings.Count(stdout.String(), "\n"); got != 2 {
+		t.Errorf("unexpected number of entries below error\nwant: 2\ngot: %d\n%s", got, stdout)
+	}
+	if strings.Contains(stdout.String(), "ERROR") {
+		t.Errorf("error written to stdout sink: %s", stdout)
+	}
+	if got := strings.Count(stderr.String(), "\n"); got != 2 {
+		t.Errorf("unexpected number of entries at or above error\nwant: 2\ngot: %d\n%s", got, stderr)
+	}
+	if strings.Contains(stderr.String(), "INFO") {
+		t.Errorf("info written to stderr sink: %s", stderr)
+	}
+}
+
+func TestSinkFilter(t *testing.T) {
+	out := bytes.NewBuffer(make([]byte, 0, 1024))
+	s := NewSink(out)
+	s.SetFilter(func(e *Entry) bool { return e.Labels["audit"] == "true" })
+	logger := New(s)
+	logger.Info("filtered")
+	logger.WithLabels(Fields{"audit": true}).Info("included")
+	got := out.String()
+	if strings.Contains(got, "filtered") || !strings.Contains(got, "included") {
+		t.Errorf("filter not applied\ngot: %s", got)
+	}
+}
+
+func TestSinkEncoder(t *testing.T) {
+	out := bytes.NewBuffer(make([]byte, 0, 1024))
+	s := NewSink(out)
+	s.SetEncoder(EncoderFunc(func(buf *bytes.Buffer, e *Entry) error {
+		fmt.Fprintf(buf, "%s %s\n", e.Severity, e.Message)
+		return nil
+	}))
+	New(s).Notice("hello")
+	if got, want := out.String(), "NOTICE hello\n"; got != want {
+		t.Errorf("encoder not used\nwant: %q\ngot: %q", want, got)
+	}
+}
+
+func TestAsyncSinkDoesNotBlock(t *testing.T) {
+	slow := &blockingWriter{release: make(chan struct{})}
+	fast := bytes.NewBuffer(make([]byte, 0, 4096))
+	async := NewAsyncSink(slow, 2)
+	fastSink := NewSink(fast)
+	logger := New(async, fastSink)
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 10; i++ {
+			logger.Info("hello")
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("slow sink blocked logging")
+	}
+	if err := fastSink.Flush(); err != nil {
+		t.Fatalf("flushing: %v", err)
+	}
+	if got := strings.Count(fast.String(), "\n"); got != 10 {
+		t.Errorf("fast sink missing entries\nwant: 10\ngot: %d", got)
+	}
+	if async.Dropped() == 0 {
+		t.Error("expected dropped entries for full buffer")
+	}
+
+	close(slow.release)
+	if err := logger.Flush(); err != nil {
+		t.Fatalf("flushing: %v", err)
+	}
+	written := strings.Count(slow.String(), "\n")
+	if uint64(written)+async.Dropped() != 10 {
+		t.Errorf("entries lost\nwritten: %d, dropped: %d", written, async.Dropped())
+	}
+
+	if err := async.Close(); err != nil {
+		t.Fatalf("closing: %v", err)
+	}
+	logger.Info("after close")
+	_ = async.Flush()
+	if got := strings.Count(slow.String(), "\n"); got != written {
+		t.Errorf("closed sink still written to: %s", slow)
+	}
+	if err := async.Close(); err != nil {
+		t.Errorf("closing twice: %v", err)
+	}
+}
+
+func TestSlowSinkDoesNotBlockOthers(t *testing.T) {
+	slow := &blockingWriter{release: make(chan struct{})}
+	fast := &blockingWriter{release: make(chan struct{})}
+	close(fast.release)
+	slowSink := NewAsyncSink(slow, 16)
+	logger := New(slowSink, NewSink(fast))
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 10; i++ {
+			logger.Info("hello")
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("slow asynchronous sink blocked logging")
+	}
+	// Synchronous sinks are written before logging returns, even alongside others.
+	if got := strings.Count(fast.String(), "\n"); got != 10 {
+		t.Errorf("synchronous sink missing entries\nwant: 10\ngot: %d", got)
+	}
+
+	close(slow.release)
+	if err := logger.Flush(); err != nil {
+		t.Fatalf("flushing: %v", err)
+	}
+	if got := strings.Count(slow.String(), "\n"); got != 10 {
+		t.Errorf("slow sink missing entries once released\nwant: 10\ngot: %d", got)
+	}
+	if err := slowSink.Close(); err != nil {
+		t.Errorf("closing: %v", err)
+	}
+}
@@ -1,52 +1,61 @@
 // Package slog implements a logger formatted to work with Stackdriver structured logs.
+//
+// Detail values are normalized when written so they read the same in every encoder: durations are written as
+// strings such as "1.5s" rather than nanoseconds, times in RFC 3339, errors as their message, protocol buffer
+// messages with protojson and NaN or infinite floats as "NaN", "+Inf" or "-Inf". Earlier versions wrote
+// durations and errors as encoding/json does, so queries on those details may need updating. Values nested in
+// structs and typed maps or slices are still encoded by encoding/json.
 package slog
 
 import (
 	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
 	"io"
+	"net"
 	"os"
 	"runtime"
 	"sync"
+	"sync/atomic"
 
 	"go.opencensus.io/trace"
 )
 
-// Severity levels as specified in https://cloud.google.com/logging/docs/reference/v2/rest/v2/LogEntry#LogSeverity.
-type severity string
-
 type key int
 
 type Fields map[string]interface{}
 type stack []uintptr
 
-const (
-	severityDebug     severity = "DEBUG"
-	severityInfo      severity = "INFO"
-	severityNotice    severity = "NOTICE"
-	severityWarn      severity = "WARNING"
-	severityError     severity = "ERROR"
-	severityCritical  severity = "CRITICAL"
-	severityAlert     severity = "ALERT"
-	severityEmergency severity = "EMERGENCY"
-)
-
 var (
 	std      = newLogger(os.Stdout)
 	base     = std.entry()
-	sources  = make(map[uintptr]*SourceLocation)
+	sources  = make(map[sourceKey]*cachedSource)
 	sourceMu sync.RWMutex
 	entryKey key
 )
 
+// sourceKey for cached sources, each trimmer caches its own trimmed values.
+type sourceKey struct {
+	pc   uintptr
+	trim *trimmer
+}
+
 // Logger used to write structured logs in a thread-safe manner to a given output.
 type Logger struct {
-	mu      sync.Mutex // ensures atomic writes
-	encoder *json.Encoder
-	sources bool
-	project string
+	counters     counters   // first to keep 64-bit alignment for atomic access
+	mu           sync.Mutex // ensures atomic writes
+	sinks        []*Sink
+	sources      bool
+	project      string
+	labels       map[string]string
+	hooks        []*hook
+	metricLabels []metricLabel
+	fatal        fatalConfig
+	stack        stackConfig
+	trim         *trimmer
+	dedup        *dedup
+	norm         normalizer
+	proxies      []*net.IPNet
 }
 
 // Entry with additional metadata included.
@@ -54,8 +63,12 @@ type Logger struct {
 type Entry struct {
 	logger         *Logger
 	stack          stack
+	skip           int
+	stackSource    bool // source location is the first frame of stack, such as where a panic was raised
+	fields         []Field
+	norm           normalizer
 	Message        string            `json:"message"`
-	Severity       severity          `json:"severity,omitempty"`
+	Severity       Severity          `json:"severity,omitempty"`
 	Labels         map[string]string `json:"logging.googleapis.com/labels,omitempty"`
 	SourceLocation *SourceLocation   `json:"logging.googleapis.com/sourceLocation,omitempty"`
 	Operation      *Operation        `json:"logging.googleapis.com/operation,omitempty"`
@@ -65,6 +78,8 @@ type Entry struct {
 	Details        Fields            `json:"details,omitempty"`
 	Err            string            `json:"error,omitempty"`
 	StackTrace     string            `json:"exception,omitempty"`
+	ProtoPayload   interface{}       `json:"protoPayload,omitempty"`
+	HTTPRequest    *HTTPRequest      `json:"httpRequest,omitempty"`
 }
 
 // SourceLocation that originated the log call.
@@ -103,15 +118,11 @@ func (e *Entry) clone() *Entry {
 // startOperation with a given ID and producer.
 // Will log the start of the operation at Notice level.
 func (e *Entry) startOperation(id, producer string) *Entry {
-	e.Operation = &Operation{
-		ID:       id,
-		Producer: producer,
-		First:    true,
-		Last:     false,
-	}
-	e.logger.log(e, severityNotice, fmt.Sprint(producer, " starting operation ", id), 3)
-	e.Operation.First = false
-	return e
+	c := e.WithOperation(id, producer)
+	first := *c
+	first.Operation = &Operation{ID: id, Producer: producer, First: true}
+	c.logger.log(&first, SeverityNotice, fmt.Sprint(producer, " starting operation ", id), 3)
+	return c
 }
 
 // StartOperation with a given ID and producer.
@@ -132,34 +143,33 @@ func (l *Logger) StartOperation(id, producer string) *Entry {
 	return l.entry().startOperation(id, producer)
 }
 
-// EndOperation stops any current operation and further logs will no longer include.
-// Will log the end of the operation at Notice level.
+// EndOperation logs the end of the current operation at Notice level.
+// The Entry is left unchanged, log with its parent for entries outside of the operation.
 func (e *Entry) EndOperation() {
 	if e.Operation == nil {
 		return
 	}
-	e.Operation.Last = true
-	e.logger.log(e, severityNotice, fmt.Sprint(e.Operation.Producer, " ending operation ", e.Operation.ID), 2)
-	e.Operation = nil
+	last := *e
+	op := *e.Operation
+	op.Last = true
+	last.Operation = &op
+	e.logger.log(&last, SeverityNotice, fmt.Sprint(op.Producer, " ending operation ", op.ID), 2)
 }
 
-// WithOperation details included in all logs written for a given Entry.
+// WithOperation details included in all logs written for a given Entry. Will create a child entry.
 func (e *Entry) WithOperation(id, producer string) *Entry {
-	e.Operation = &Operation{
-		ID:       id,
-		Producer: producer,
-		First:    false,
-		Last:     false,
-	}
-	return e
+	// Only the operation changes, so the maps are shared with the parent as they are never written to.
+	next := *e
+	next.Operation = &Operation{ID: id, Producer: producer}
+	return &next
 }
 
-// WithOperation details included in all logs written for a given Entry.
+// WithOperation details included in all logs written for a given Entry. Will create a child entry.
 func WithOperation(id, producer string) *Entry {
 	return std.entry().WithOperation(id, producer)
 }
 
-// WithOperation details included in all logs written for a given Entry.
+// WithOperation details included in all logs written for a given Entry. Will create a child entry.
 func (l *Logger) WithOperation(id, producer string) *Entry {
 	return l.entry().WithOperation(id, producer)
 }
@@ -270,10 +280,7 @@ func (l *Logger) WithDetails(details Fields) *Entry {
 
 func (e *Entry) withStack(skip int) *Entry {
 	c := e.clone()
-	const depth = 16
-	var pcs [depth]uintptr
-	n := runtime.Callers(skip, pcs[:])
-	c.stack = pcs[0:n]
+	c.stack = e.logger.stackConfig().callers(skip + 1)
 	return c
 }
 
@@ -294,7 +301,7 @@ func (l *Logger) WithStack() *Entry {
 
 // newLogger with provided options.
 func newLogger(out io.Writer) *Logger {
-	return &Logger{encoder: json.NewEncoder(out), sources: true}
+	return New(NewSink(out))
 }
 
 // entry creates a new Entry allowing for reusing details across multiple log calls.
@@ -302,14 +309,12 @@ func (l *Logger) entry() *Entry {
 	return &Entry{logger: l}
 }
 
-// SetOutput destination for the logger.
+// SetOutput destination for the logger, replacing any existing sinks.
 func (l *Logger) SetOutput(w io.Writer) {
-	l.mu.Lock()
-	defer l.mu.Unlock()
-	l.encoder = json.NewEncoder(w)
+	l.SetSinks(NewSink(w))
 }
 
-// SetOutput destination for the package-level logger.
+// SetOutput destination for the package-level logger, replacing any existing sinks.
 func SetOutput(w io.Writer) {
 	std.SetOutput(w)
 }
@@ -340,41 +345,90 @@ func SetIncludeSources(include bool) {
 	std.SetIncludeSources(include)
 }
 
+// cachedSource of a program counter, with whether its function was a helper as of a generation of helpers.
+type cachedSource struct {
+	source *SourceLocation
+	helper bool
+	gen    int32
+}
+
 // getSource from reflection, caches where possible to shave some time off.
-func getSource(depth int) *SourceLocation {
+// Only callers whose function is a helper search further up the stack.
+func getSource(depth int, trim *trimmer) *SourceLocation {
 	pc, file, line, ok := runtime.Caller(depth + 1)
 	if !ok {
 		return nil
 	}
+	k := sourceKey{pc: pc, trim: trim}
+	gen := atomic.LoadInt32(&helperGen)
 	sourceMu.RLock()
-	s := sources[pc]
+	c := sources[k]
 	sourceMu.RUnlock()
+	if c == nil || c.gen != gen {
+		c = cacheSource(k, file, line, gen)
+	}
+	if !c.helper {
+		return c.source
+	}
+	s := callerSource(depth + 1)
 	if s != nil {
-		return s
+		trim.trim(s)
 	}
-	sourceMu.Lock()
-	defer sourceMu.Unlock()
-	s = &SourceLocation{
+	return s
+}
+
+// cacheSource of a program counter for a generation of helpers.
+func cacheSource(k sourceKey, file string, line int, gen int32) *cachedSource {
+	s := &SourceLocation{
 		File: file,
 		Line: fmt.Sprint(line),
 	}
-	fn := runtime.FuncForPC(pc)
+	fn := runtime.FuncForPC(k.pc)
 	if fn != nil {
 		s.Function = fn.Name()
 	}
-	sources[pc] = s
+	c := &cachedSource{helper: isHelper(s.Function), gen: gen}
+	k.trim.trim(s)
+	c.source = s
+	sourceMu.Lock()
+	defer sourceMu.Unlock()
+	sources[k] = c
+	return c
+}
+
+// stackSource of the first frame of a stack.
+func stackSource(st stack, trim *trimmer) *SourceLocation {
+	frame, _ := runtime.CallersFrames(st[:1]).Next()
+	s := &SourceLocation{
+		File:     frame.File,
+		Line:     fmt.Sprint(frame.Line),
+		Function: frame.Function,
+	}
+	trim.trim(s)
 	return s
 }
 
 // format the stack as error reporting expects it.
-func formatStackTrace(errstr string, s stack) string {
+// Frames matching the filters are skipped and at most depth frames are included.
+// The first frame is always kept so Error Reporting can group errors.
+func formatStackTrace(errstr string, s stack, cfg stackConfig) string {
 	buf := bytes.NewBuffer(make([]byte, 0, 1024))
 	fmt.Fprint(buf, errstr, ":\n\n")
 	fmt.Fprint(buf, "goroutine 0 [???]:\n")
 	frames := runtime.CallersFrames(s)
-	for {
+	written := 0
+	leading := true
+	for written < cfg.depth() {
 		frame, more := frames.Next()
-		fmt.Fprintf(buf, "%s(...)\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+		// Helpers are dropped from the top of the stack so it starts where they were called.
+		if leading && more && isHelper(frame.Function) {
+			continue
+		}
+		leading = false
+		if written == 0 || !cfg.filtered(frame.Function) {
+			fmt.Fprintf(buf, "%s(...)\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+			written++
+		}
 		if !more {
 			break
 		}
@@ -383,323 +437,399 @@ func formatStackTrace(errstr string, s stack) string {
 }
 
 // log with given parameters.
-func (l *Logger) log(e *Entry, s severity, m string, depth int) {
+func (l *Logger) log(e *Entry, s Severity, m string, depth int) {
 	// Do costly operations prior to grabbing mutex
+	l.mu.Lock()
+	includeSources, trim, cfg := l.sources, l.trim, l.stack
+	l.mu.Unlock()
+
 	var source *SourceLocation
-	if l.sources {
-		source = getSource(depth)
+	switch {
+	case !includeSources:
+	case e.stackSource && len(e.stack) > 0:
+		source = stackSource(e.stack, trim)
+	default:
+		source = getSource(depth+e.skip, trim)
 	}
 
 	var stacktrace string
-	if len(e.stack) > 0 {
+	st := e.stack
+	if len(st) == 0 && s >= cfg.severity() {
+		st = cfg.callers(depth + 2 + e.skip)
+	}
+	if len(st) > 0 {
 		var errstr string
 		if len(e.Err) > 0 {
 			errstr = e.Err
 		} else {
 			errstr = m
 		}
-		stacktrace = formatStackTrace(errstr, e.stack)
+		stacktrace = formatStackTrace(errstr, st, cfg)
 	}
 
+	// Each call writes its own record so an Entry shared between goroutines is never modified.
+	r := *e
+	r.Severity = s
+	r.Message = m
+	r.SourceLocation = source
+	r.StackTrace = stacktrace
+
 	l.mu.Lock()
-	defer l.mu.Unlock()
+	r.norm = l.norm
+	// Default labels and typed fields are merged into the record, leaving the maps of the Entry untouched.
+	if len(l.labels) > 0 {
+		r.Labels = mergeLabels(l.labels, r.Labels)
+	}
+	if len(r.fields) > 0 {
+		r.Labels = mergeLabelFields(r.Labels, r.fields)
+	}
 
-	e.Severity = s
-	e.Message = m
-	e.SourceLocation = source
-	e.StackTrace = stacktrace
+	if l.dedup != nil && l.dedup.suppress(l, &r) {
+		labels, values := l.metricLabels, l.metricValues(r.Labels)
+		l.mu.Unlock()
+		l.count(r.Severity, labels, values)
+		return
+	}
+	w := l.write(&r)
+	l.mu.Unlock()
+	l.finish(w)
+}
+
+// written entry awaiting metrics and hooks, which run without holding the mutex.
+type written struct {
+	severity     Severity
+	hooks        []*hook
+	snapshot     *Entry
+	metricLabels []metricLabel
+	metricValues []string
+	repeats      bool // summary of repeats that were counted when suppressed
+}
 
-	if err := l.encoder.Encode(e); err != nil {
-		fmt.Fprintln(os.Stderr, "could not marshal log:", err)
+// write an entry to all sinks. Must be called with mu held, then finish once it is released.
+func (l *Logger) write(e *Entry) written {
+	for _, sink := range l.sinks {
+		sink.write(e)
 	}
+
+	w := written{severity: e.Severity, hooks: l.hooks, metricLabels: l.metricLabels}
+	// Hooks get a copy so they can run without holding the mutex.
+	if len(w.hooks) > 0 {
+		w.snapshot = new(Entry)
+		*w.snapshot = *e
+		w.snapshot.Details = e.mergedDetails()
+	}
+	w.metricValues = l.metricValues(e.Labels)
+	return w
+}
+
+// finish a write by counting it and firing hooks.
+func (l *Logger) finish(w written) {
+	if !w.repeats {
+		l.count(w.severity, w.metricLabels, w.metricValues)
+	}
+	for _, h := range w.hooks {
+		h.fire(w.snapshot)
+	}
+}
+
+// mergeLabels into a new map, with labels from b taking precedence over a.
+func mergeLabels(a, b map[string]string) map[string]string {
+	m := make(map[string]string, len(a)+len(b))
+	for k, v := range a {
+		m[k] = v
+	}
+	for k, v := range b {
+		m[k] = v
+	}
+	return m
 }
 
 // Debug sends a message to the logger with severity Debug.
 // Arguments are handled in the manner of fmt.Print.
 func (l *Logger) Debug(v ...interface{}) {
-	l.log(base, severityDebug, fmt.Sprint(v...), 2)
+	l.log(base, SeverityDebug, fmt.Sprint(v...), 2)
 }
 
 // Debug sends a message to the default logger with severity Debug.
 // Arguments are handled in the manner of fmt.Print.
 func Debug(v ...interface{}) {
-	std.log(base, severityDebug, fmt.Sprint(v...), 2)
+	std.log(base, SeverityDebug, fmt.Sprint(v...), 2)
 }
 
 // Debug sends a message to the logger associated with this entry with severity Debug.
 // Arguments are handled in the manner of fmt.Print.
 func (e *Entry) Debug(v ...interface{}) {
-	e.logger.log(e, severityDebug, fmt.Sprint(v...), 2)
+	e.logger.log(e, SeverityDebug, fmt.Sprint(v...), 2)
 }
 
 // Debugf sends a message to the logger with severity Debug.
 // Arguments are handled in the manner of fmt.Printf.
 func (l *Logger) Debugf(format string, v ...interface{}) {
-	l.log(base, severityDebug, fmt.Sprintf(format, v...), 2)
+	l.log(base, SeverityDebug, fmt.Sprintf(format, v...), 2)
 }
 
 // Debugf sends a message to the default logger with severity Debug.
 // Arguments are handled in the manner of fmt.Printf.
 func Debugf(format string, v ...interface{}) {
-	std.log(base, severityDebug, fmt.Sprintf(format, v...), 2)
+	std.log(base, SeverityDebug, fmt.Sprintf(format, v...), 2)
 }
 
 // Debugf sends a message to the logger associated with this entry with severity Debug.
 // Arguments are handled in the manner of fmt.Printf.
 func (e *Entry) Debugf(format string, v ...interface{}) {
-	e.logger.log(e, severityDebug, fmt.Sprintf(format, v...), 2)
+	e.logger.log(e, SeverityDebug, fmt.Sprintf(format, v...), 2)
 }
 
 // Info sends a message to the logger with severity Info.
 // Arguments are handled in the manner of fmt.Print.
 func (l *Logger) Info(v ...interface{}) {
-	l.log(base, severityInfo, fmt.Sprint(v...), 2)
+	l.log(base, SeverityInfo, fmt.Sprint(v...), 2)
 }
 
 // Info sends a message to the default logger with severity Info.
 // Arguments are handled in the manner of fmt.Print.
 func Info(v ...interface{}) {
-	std.log(base, severityInfo, fmt.Sprint(v...), 2)
+	std.log(base, SeverityInfo, fmt.Sprint(v...), 2)
 }
 
 // Info sends a message to the logger associated with this entry with severity Info.
 // Arguments are handled in the manner of fmt.Print.
 func (e *Entry) Info(v ...interface{}) {
-	e.logger.log(e, severityInfo, fmt.Sprint(v...), 2)
+	e.logger.log(e, SeverityInfo, fmt.Sprint(v...), 2)
 }
 
 // Infof sends a message to the logger with severity Info.
 // Arguments are handled in the manner of fmt.Printf.
 func (l *Logger) Infof(format string, v ...interface{}) {
-	l.log(base, severityInfo, fmt.Sprintf(format, v...), 2)
+	l.log(base, SeverityInfo, fmt.Sprintf(format, v...), 2)
 }
 
 // Infof sends a message to the default logger with severity Info.
 // Arguments are handled in the manner of fmt.Printf.
 func Infof(format string, v ...interface{}) {
-	std.log(base, severityInfo, fmt.Sprintf(format, v...), 2)
+	std.log(base, SeverityInfo, fmt.Sprintf(format, v...), 2)
 }
 
 // Infof sends a message to the logger associated with this entry with severity Info.
 // Arguments are handled in the manner of fmt.Printf.
 func (e *Entry) Infof(format string, v ...interface{}) {
-	e.logger.log(e, severityInfo, fmt.Sprintf(format, v...), 2)
+	e.logger.log(e, SeverityInfo, fmt.Sprintf(format, v...), 2)
 }
 
 // Notice sends a message to the logger with severity Notice.
 // Arguments are handled in the manner of fmt.Print.
 func (l *Logger) Notice(v ...interface{}) {
-	l.log(base, severityNotice, fmt.Sprint(v...), 2)
+	l.log(base, SeverityNotice, fmt.Sprint(v...), 2)
 }
 
 // Notice sends a message to the default logger with severity Notice.
 // Arguments are handled in the manner of fmt.Print.
 func Notice(v ...interface{}) {
-	std.log(base, severityNotice, fmt.Sprint(v...), 2)
+	std.log(base, SeverityNotice, fmt.Sprint(v...), 2)
 }
 
 // Notice sends a message to the logger associated with this entry with severity Notice.
 // Arguments are handled in the manner of fmt.Print.
 func (e *Entry) Notice(v ...interface{}) {
-	e.logger.log(e, severityNotice, fmt.Sprint(v...), 2)
+	e.logger.log(e, SeverityNotice, fmt.Sprint(v...), 2)
 }
 
 // Noticef sends a message to the logger with severity Notice.
 // Arguments are handled in the manner of fmt.Printf.
 func (l *Logger) Noticef(format string, v ...interface{}) {
-	l.log(base, severityNotice, fmt.Sprintf(format, v...), 2)
+	l.log(base, SeverityNotice, fmt.Sprintf(format, v...), 2)
 }
 
 // Noticef sends a message to the default logger with severity Notice.
 // Arguments are handled in the manner of fmt.Printf.
 func Noticef(format string, v ...interface{}) {
-	std.log(base, severityNotice, fmt.Sprintf(format, v...), 2)
+	std.log(base, SeverityNotice, fmt.Sprintf(format, v...), 2)
 }
 
 // Noticef sends a message to the logger associated with this entry with severity Notice.
 // Arguments are handled in the manner of fmt.Printf.
 func (e *Entry) Noticef(format string, v ...interface{}) {
-	e.logger.log(e, severityNotice, fmt.Sprintf(format, v...), 2)
+	e.logger.log(e, SeverityNotice, fmt.Sprintf(format, v...), 2)
 }
 
 // Warn sends a message to the logger with severity Warn.
 // Arguments are handled in the manner of fmt.Print.
 func (l *Logger) Warn(v ...interface{}) {
-	l.log(base, severityWarn, fmt.Sprint(v...), 2)
+	l.log(base, SeverityWarning, fmt.Sprint(v...), 2)
 }
 
 // Warn sends a message to the default logger with severity Warn.
 // Arguments are handled in the manner of fmt.Print.
 func Warn(v ...interface{}) {
-	std.log(base, severityWarn, fmt.Sprint(v...), 2)
+	std.log(base, SeverityWarning, fmt.Sprint(v...), 2)
 }
 
 // Warn sends a message to the logger associated with this entry with severity Warn.
 // Arguments are handled in the manner of fmt.Print.
 func (e *Entry) Warn(v ...interface{}) {
-	e.logger.log(e, severityWarn, fmt.Sprint(v...), 2)
+	e.logger.log(e, SeverityWarning, fmt.Sprint(v...), 2)
 }
 
 // Warnf sends a message to the logger with severity Warn.
 // Arguments are handled in the manner of fmt.Printf.
 func (l *Logger) Warnf(format string, v ...interface{}) {
-	l.log(base, severityWarn, fmt.Sprintf(format, v...), 2)
+	l.log(base, SeverityWarning, fmt.Sprintf(format, v...), 2)
 }
 
 // Warnf sends a message to the default logger with severity Warn.
 // Arguments are handled in the manner of fmt.Printf.
 func Warnf(format string, v ...interface{}) {
-	std.log(base, severityWarn, fmt.Sprintf(format, v...), 2)
+	std.log(base, SeverityWarning, fmt.Sprintf(format, v...), 2)
 }
 
 // Warnf sends a message to the logger associated with this entry with severity Warn.
 // Arguments are handled in the manner of fmt.Printf.
 func (e *Entry) Warnf(format string, v ...interface{}) {
-	e.logger.log(e, severityWarn, fmt.Sprintf(format, v...), 2)
+	e.logger.log(e, SeverityWarning, fmt.Sprintf(format, v...), 2)
 }
 
 // Error sends a message to the logger with severity Error.
 // Arguments are handled in the manner of fmt.Print.
 func (l *Logger) Error(v ...interface{}) {
-	l.log(base.withStack(3), severityError, fmt.Sprint(v...), 2)
+	l.log(base, SeverityError, fmt.Sprint(v...), 2)
 }
 
 // Error sends a message to the default logger with severity Error.
 // Arguments are handled in the manner of fmt.Print.
 func Error(v ...interface{}) {
-	std.log(base.withStack(3), severityError, fmt.Sprint(v...), 2)
+	std.log(base, SeverityError, fmt.Sprint(v...), 2)
 }
 
 // Error sends a message to the logger associated with this entry with severity Error.
 // Arguments are handled in the manner of fmt.Print.
 func (e *Entry) Error(v ...interface{}) {
-	e.logger.log(e.withStack(3), severityError, fmt.Sprint(v...), 2)
+	e.logger.log(e, SeverityError, fmt.Sprint(v...), 2)
 }
 
 // Errorf sends a message to the logger with severity Error.
 // Arguments are handled in the manner of fmt.Printf.
 func (l *Logger) Errorf(format string, v ...interface{}) {
-	l.log(base.withStack(3), severityError, fmt.Sprintf(format, v...), 2)
+	l.log(base, SeverityError, fmt.Sprintf(format, v...), 2)
 }
 
 // Errorf sends a message to the default logger with severity Error.
 // Arguments are handled in the manner of fmt.Printf.
 func Errorf(format string, v ...interface{}) {
-	std.log(base.withStack(3), severityError, fmt.Sprintf(format, v...), 2)
+	std.log(base, SeverityError, fmt.Sprintf(format, v...), 2)
 }
 
 // Errorf sends a message to the logger associated with this entry with severity Error.
 // Arguments are handled in the manner of fmt.Printf.
 func (e *Entry) Errorf(format string, v ...interface{}) {
-	e.logger.log(e.withStack(3), severityError, fmt.Sprintf(format, v...), 2)
+	e.logger.log(e, SeverityError, fmt.Sprintf(format, v...), 2)
 }
 
 // Critical sends a message to the logger with severity Critical.
 // Arguments are handled in the manner of fmt.Print.
 func (l *Logger) Critical(v ...interface{}) {
-	l.log(base.withStack(3), severityCritical, fmt.Sprint(v...), 2)
+	l.log(base, SeverityCritical, fmt.Sprint(v...), 2)
 }
 
 // Critical sends a message to the default logger with severity Critical.
 // Arguments are handled in the manner of fmt.Print.
 func Critical(v ...interface{}) {
-	std.log(base.withStack(3), severityCritical, fmt.Sprint(v...), 2)
+	std.log(base, SeverityCritical, fmt.Sprint(v...), 2)
 }
 
 // Critical sends a message to the logger associated with this entry with severity Critical.
 // Arguments are handled in the manner of fmt.Print.
 func (e *Entry) Critical(v ...interface{}) {
-	e.logger.log(e.withStack(3), severityCritical, fmt.Sprint(v...), 2)
+	e.logger.log(e, SeverityCritical, fmt.Sprint(v...), 2)
 }
 
 // Criticalf sends a message to the logger with severity Critical.
 // Arguments are handled in the manner of fmt.Printf.
 func (l *Logger) Criticalf(format string, v ...interface{}) {
-	l.log(base.withStack(3), severityCritical, fmt.Sprintf(format, v...), 2)
+	l.log(base, SeverityCritical, fmt.Sprintf(format, v...), 2)
 }
 
 // Criticalf sends a message to the default logger with severity Critical.
 // Arguments are handled in the manner of fmt.Printf.
 func Criticalf(format string, v ...interface{}) {
-	std.log(base.withStack(3), severityCritical, fmt.Sprintf(format, v...), 2)
+	std.log(base, SeverityCritical, fmt.Sprintf(format, v...), 2)
 }
 
 // Criticalf sends a message to the logger associated with this entry with severity Critical.
 // Arguments are handled in the manner of fmt.Printf.
 func (e *Entry) Criticalf(format string, v ...interface{}) {
-	e.logger.log(e.withStack(3), severityCritical, fmt.Sprintf(format, v...), 2)
+	e.logger.log(e, SeverityCritical, fmt.Sprintf(format, v...), 2)
 }
 
 // Alert sends a message to the logger with severity Alert.
 // Arguments are handled in the manner of fmt.Print.
 func (l *Logger) Alert(v ...interface{}) {
-	l.log(base.withStack(3), severityAlert, fmt.Sprint(v...), 2)
+	l.log(base, SeverityAlert, fmt.Sprint(v...), 2)
 }
 
 // Alert sends a message to the default logger with severity Alert.
 // Arguments are handled in the manner of fmt.Print.
 func Alert(v ...interface{}) {
-	std.log(base.withStack(3), severityAlert, fmt.Sprint(v...), 2)
+	std.log(base, SeverityAlert, fmt.Sprint(v...), 2)
 }
 
 // Alert sends a message to the logger associated with this entry with severity Alert.
 // Arguments are handled in the manner of fmt.Print.
 func (e *Entry) Alert(v ...interface{}) {
-	e.logger.log(e.withStack(3), severityAlert, fmt.Sprint(v...), 2)
+	e.logger.log(e, SeverityAlert, fmt.Sprint(v...), 2)
 }
 
 // Alertf sends a message to the logger with severity Alert.
 // Arguments are handled in the manner of fmt.Printf.
 func (l *Logger) Alertf(format string, v ...interface{}) {
-	l.log(base.withStack(3), severityAlert, fmt.Sprintf(format, v...), 2)
+	l.log(base, SeverityAlert, fmt.Sprintf(format, v...), 2)
 }
 
 // Alertf sends a message to the default logger with severity Alert.
 // Arguments are handled in the manner of fmt.Printf.
 func Alertf(format string, v ...interface{}) {
-	std.log(base.withStack(3), severityAlert, fmt.Sprintf(format, v...), 2)
+	std.log(base, SeverityAlert, fmt.Sprintf(format, v...), 2)
 }
 
 // Alertf sends a message to the logger associated with this entry with severity Alert.
 // Arguments are handled in the manner of fmt.Printf.
 func (e *Entry) Alertf(format string, v ...interface{}) {
-	e.logger.log(e.withStack(3), severityAlert, fmt.Sprintf(format, v...), 2)
+	e.logger.log(e, SeverityAlert, fmt.Sprintf(format, v...), 2)
 }
 
 // Emergency sends a message to the logger with severity Emergency.
 // Arguments are handled in the manner of fmt.Print.
 func (l *Logger) Emergency(v ...interface{}) {
-	l.log(base.withStack(3), severityEmergency, fmt.Sprint(v...), 2)
+	l.log(base, SeverityEmergency, fmt.Sprint(v...), 2)
 }
 
 // Emergency sends a message to the default logger with severity Emergency.
 // Arguments are handled in the manner of fmt.Print.
 func Emergency(v ...interface{}) {
-	std.log(base.withStack(3), severityEmergency, fmt.Sprint(v...), 2)
+	std.log(base, SeverityEmergency, fmt.Sprint(v...), 2)
 }
 
 // Emergency sends a message to the logger associated with this entry with severity Emergency.
 // Arguments are handled in the manner of fmt.Print.
 func (e *Entry) Emergency(v ...interface{}) {
-	e.logger.log(e.withStack(3), severityEmergency, fmt.Sprint(v...), 2)
+	e.logger.log(e, SeverityEmergency, fmt.Sprint(v...), 2)
 }
 
 // Emergencyf sends a message to the logger with severity Emergency.
 // Arguments are handled in the manner of fmt.Printf.
 func (l *Logger) Emergencyf(format string, v ...interface{}) {
-	l.log(base.withStack(3), severityEmergency, fmt.Sprintf(format, v...), 2)
+	l.log(base, SeverityEmergency, fmt.Sprintf(format, v...), 2)
 }
 
 // Emergencyf sends a message to the default logger with severity Emergency.
 // Arguments are handled in the manner of fmt.Printf.
 func Emergencyf(format string, v ...interface{}) {
-	std.log(base.withStack(3), severityEmergency, fmt.Sprintf(format, v...), 2)
+	std.log(base, SeverityEmergency, fmt.Sprintf(format, v...), 2)
 }
 
 // Emergencyf sends a message to the logger associated with this entry with severity Emergency.
 // Arguments are handled in the manner of fmt.Printf.
 func (e *Entry) Emergencyf(format string, v ...interface{}) {
-	e.logger.log(e.withStack(3), severityEmergency, fmt.Sprintf(format, v...), 2)
+	e.logger.log(e, SeverityEmergency, fmt.Sprintf(format, v...), 2)
 }
 
 // NewContext returns a new Context that carries an entry.
@@ -715,3 +845,23 @@ func FromContext(ctx context.Context) *Entry {
 	}
 	return entry
 }
+
+// NewLoggerContext returns a new Context that carries an entry of the logger.
+// Any entry already in ctx keeps its metadata but is written with the logger instead.
+//
+// Libraries should log with the Entry from FromContext, or the context-first functions such as InfoCtx,
+// so that applications choose the Logger by attaching it to the contexts they pass in.
+func NewLoggerContext(ctx context.Context, l *Logger) context.Context {
+	entry, ok := ctx.Value(entryKey).(*Entry)
+	if !ok {
+		return NewContext(ctx, l.entry())
+	}
+	next := *entry
+	next.logger = l
+	return NewContext(ctx, &next)
+}
+
+// LoggerFromContext returns the Logger of the entry stored in ctx, or the package-level logger if none exists.
+func LoggerFromContext(ctx context.Context) *Logger {
+	return FromContext(ctx).logger
+}
@@ -68,3 +68,34 @@ func BenchmarkLargeLog(b *testing.B) {
 		buf.Reset()
 	}
 }
+
+func BenchmarkWithDetails(b *testing.B) {
+	buf := bytes.NewBuffer(make([]byte, 0, 1024))
+	logger := newLogger(buf)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		logger.WithDetails(Fields{"patient": "abc", "count": i}).Info(benchmarkMessage)
+		buf.Reset()
+	}
+}
+
+func BenchmarkWithFields(b *testing.B) {
+	buf := bytes.NewBuffer(make([]byte, 0, 1024))
+	logger := newLogger(buf)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		logger.With(String("patient", "abc"), Int("count", i)).Info(benchmarkMessage)
+		buf.Reset()
+	}
+}
+
+func BenchmarkCount(b *testing.B) {
+	logger := newLogger(nil)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		logger.count(SeverityInfo, nil, nil)
+	}
+}
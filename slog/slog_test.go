@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -584,3 +585,73 @@ func TestRaces(t *testing.T) {
 		go Info("hello")
 	}
 }
+
+func TestSharedEntry(t *testing.T) {
+	out := &blockingWriter{release: make(chan struct{})}
+	close(out.release)
+	logger := newLogger(out)
+	logger.SetLabels(Fields{"service": "api"})
+	logger.AddHook(SeverityDefault, HookFunc(func(e *Entry) {}))
+	shared := logger.StartOperation("op", "producer").
+		WithLabels(Fields{"hello": "world"}).
+		WithDetail("key", "value").
+		With(Label("field", "label"), Int("count", 1))
+	want := *shared
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			shared.Info("shared")
+			shared.WithError(errors.New("oops")).Errorf("shared %d", i)
+			shared.WithOperation("child", "producer").Warn("child")
+			shared.With(Int("i", i)).Log(SeverityNotice, "typed")
+			shared.StartOperation("nested", "producer").EndOperation()
+			shared.EndOperation()
+		}(i)
+	}
+	wg.Wait()
+
+	if diff := cmp.Diff(&want, shared, cmpopts.IgnoreUnexported(Entry{})); diff != "" {
+		t.Errorf("shared entry modified by logging:\n%s", diff)
+	}
+	if want.Operation.First || want.Operation.Last {
+		t.Errorf("operation flags left set: %+v", want.Operation)
+	}
+	if base.Severity != SeverityDefault || base.Message != "" || base.Labels != nil {
+		t.Errorf("package entry modified by logging: %+v", base)
+	}
+}
+
+func TestOperationCopies(t *testing.T) {
+	out := bytes.NewBuffer(make([]byte, 0, 1024))
+	logger := newLogger(out)
+	parent := logger.entry()
+
+	e := parent.StartOperation("op", "producer")
+	if parent.Operation != nil {
+		t.Errorf("start operation modified the parent: %+v", parent.Operation)
+	}
+	e.Info("during")
+	e.EndOperation()
+	if e.Operation == nil || e.Operation.Last {
+		t.Errorf("end operation modified the entry: %+v", e.Operation)
+	}
+	if child := e.WithOperation("other", "producer"); child == e || e.Operation.ID != "op" {
+		t.Errorf("with operation modified the entry: %+v", e.Operation)
+	}
+
+	var ops []Operation
+	for _, entry := range decodeEntries(t, out.Bytes()) {
+		ops = append(ops, *entry.Operation)
+	}
+	want := []Operation{
+		{ID: "op", Producer: "producer", First: true},
+		{ID: "op", Producer: "producer"},
+		{ID: "op", Producer: "producer", Last: true},
+	}
+	if diff := cmp.Diff(want, ops); diff != "" {
+		t.Errorf("unexpected operations:\n%s", diff)
+	}
+}
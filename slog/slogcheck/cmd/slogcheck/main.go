@@ -0,0 +1,14 @@
+// Command slogcheck reports misuse of the tau slog package. It can be run directly or by go vet:
+//
+//	go install github.com/ParticleHealth/tau/slog/slogcheck/cmd/slogcheck@latest
+//	go vet -vettool=$(which slogcheck) ./...
+package main
+
+import (
+	"github.com/ParticleHealth/tau/slog/slogcheck"
+	"golang.org/x/tools/go/analysis/singlechecker"
+)
+
+func main() {
+	singlechecker.Main(slogcheck.Analyzer)
+}
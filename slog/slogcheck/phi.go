@@ -0,0 +1,81 @@
+package slogcheck
+
+import (
+	"strings"
+	"unicode"
+)
+
+// phiExact keys reported only when they match exactly, as they are common inside unrelated keys.
+var phiExact = []string{"dob", "ssn", "mrn", "address", "phone", "email", "zip", "gender", "sex"}
+
+// phiContains keys reported when they appear anywhere in a key.
+var phiContains = []string{
+	"socialsecurity",
+	"dateofbirth",
+	"birthdate",
+	"birthday",
+	"firstname",
+	"lastname",
+	"middlename",
+	"fullname",
+	"givenname",
+	"familyname",
+	"surname",
+	"patientname",
+	"streetaddress",
+	"homeaddress",
+	"mailingaddress",
+	"zipcode",
+	"postalcode",
+	"phonenumber",
+	"emailaddress",
+	"medicalrecord",
+	"insuranceid",
+	"memberid",
+	"subscriberid",
+	"diagnosis",
+}
+
+// phiMatcher of keys that look like they hold PHI, compared ignoring case and separators.
+type phiMatcher struct {
+	exact    map[string]bool
+	contains []string
+}
+
+// newPHIMatcher with the default patterns and any comma separated extra patterns.
+func newPHIMatcher(extra string) *phiMatcher {
+	m := &phiMatcher{exact: make(map[string]bool), contains: phiContains}
+	for _, k := range phiExact {
+		m.exact[k] = true
+	}
+	for _, k := range strings.Split(extra, ",") {
+		if k = normalizeKey(k); k != "" {
+			m.contains = append(m.contains[:len(m.contains):len(m.contains)], k)
+		}
+	}
+	return m
+}
+
+// match reports whether key looks like PHI.
+func (m *phiMatcher) match(key string) bool {
+	k := normalizeKey(key)
+	if m.exact[k] {
+		return true
+	}
+	for _, p := range m.contains {
+		if strings.Contains(k, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeKey to lower case letters and digits, so patient_name, patientName and patient-name are the same.
+func normalizeKey(k string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, k)
+}
@@ -0,0 +1,413 @@
+// Package slogcheck provides an analyzer reporting misuse of the github.com/ParticleHealth/tau/slog package.
+//
+// It reports printf style calls with formats that do not match their arguments, print style calls with
+// formatting directives, child entries from With methods that are discarded, operations that are started but
+// never ended, and detail or label keys that look like they hold protected health information (PHI).
+package slogcheck
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/types"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// slogPath of the package checked.
+const slogPath = "github.com/ParticleHealth/tau/slog"
+
+const doc = `check for misuse of the tau slog package
+
+Reports printf style calls such as Infof whose format does not match their arguments, print style
+calls such as Info with formatting directives, child entries from With methods that are discarded,
+entries from StartOperation that never have EndOperation called, and detail or label keys that look
+like protected health information.`
+
+// Analyzer for misuse of the slog package.
+var Analyzer = &analysis.Analyzer{
+	Name:     "slogcheck",
+	Doc:      doc,
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+// phiFlag of additional key patterns, comma separated.
+var phiFlag string
+
+func init() {
+	Analyzer.Flags.StringVar(&phiFlag, "phi", "", "comma separated key patterns to report as PHI in addition to the defaults")
+}
+
+// printfFuncs taking a format and arguments, by name.
+var printfFuncs = map[string]bool{
+	"Debugf":     true,
+	"Infof":      true,
+	"Noticef":    true,
+	"Warnf":      true,
+	"Errorf":     true,
+	"Criticalf":  true,
+	"Alertf":     true,
+	"Emergencyf": true,
+	"Fatalf":     true,
+}
+
+// printFuncs taking a message, by name.
+var printFuncs = map[string]bool{
+	"Debug":     true,
+	"Info":      true,
+	"Notice":    true,
+	"Warn":      true,
+	"Error":     true,
+	"Critical":  true,
+	"Alert":     true,
+	"Emergency": true,
+	"Fatal":     true,
+}
+
+// keyFuncs with a detail or label key as their first argument.
+var keyFuncs = map[string]bool{
+	"WithDetail": true,
+	"String":     true,
+	"Int":        true,
+	"Int64":      true,
+	"Float64":    true,
+	"Bool":       true,
+	"Duration":   true,
+	"Time":       true,
+	"Any":        true,
+	"Label":      true,
+}
+
+// mapFuncs with a map of details or labels as their first argument.
+var mapFuncs = map[string]bool{
+	"WithDetails": true,
+	"WithLabels":  true,
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	if !imports(pass.Pkg, slogPath) {
+		return nil, nil
+	}
+	c := &checker{pass: pass, phi: newPHIMatcher(phiFlag)}
+	ins := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	nodes := []ast.Node{(*ast.CallExpr)(nil), (*ast.ExprStmt)(nil), (*ast.FuncDecl)(nil), (*ast.FuncLit)(nil)}
+	ins.Preorder(nodes, func(n ast.Node) {
+		switch n := n.(type) {
+		case *ast.CallExpr:
+			c.call(n)
+		case *ast.ExprStmt:
+			c.discarded(n)
+		case *ast.FuncDecl:
+			if n.Body != nil {
+				c.operations(n.Body)
+			}
+		case *ast.FuncLit:
+			c.operations(n.Body)
+		}
+	})
+	return nil, nil
+}
+
+// imports reports whether pkg is, or directly imports, the package with the given path.
+func imports(pkg *types.Package, path string) bool {
+	if pkg.Path() == path {
+		return true
+	}
+	for _, imp := range pkg.Imports() {
+		if imp.Path() == path {
+			return true
+		}
+	}
+	return false
+}
+
+// checker of a single package.
+type checker struct {
+	pass *analysis.Pass
+	phi  *phiMatcher
+}
+
+// slogFunc called, or nil if the call is not to the slog package.
+func (c *checker) slogFunc(call *ast.CallExpr) *types.Func {
+	fn, ok := typeutil.Callee(c.pass.TypesInfo, call).(*types.Func)
+	if !ok || fn.Pkg() == nil || fn.Pkg().Path() != slogPath {
+		return nil
+	}
+	return fn
+}
+
+// call to check printf, print and key usage.
+func (c *checker) call(call *ast.CallExpr) {
+	fn := c.slogFunc(call)
+	if fn == nil || len(call.Args) == 0 {
+		return
+	}
+	name, args := fn.Name(), call.Args
+	if base := strings.TrimSuffix(name, "Ctx"); printfFuncs[base] || printFuncs[base] {
+		// Context-first functions such as InfoCtx take the context before the message.
+		if base != name {
+			if len(args) < 2 {
+				return
+			}
+			args = args[1:]
+		}
+		if printfFuncs[base] {
+			c.printf(call, args, fn.Name())
+		} else {
+			c.print(call, args, fn.Name(), base+"f"+name[len(base):])
+		}
+		return
+	}
+	switch {
+	case keyFuncs[name]:
+		c.key(call.Args[0])
+	case mapFuncs[name]:
+		if lit, ok := ast.Unparen(call.Args[0]).(*ast.CompositeLit); ok {
+			for _, elt := range lit.Elts {
+				if kv, ok := elt.(*ast.KeyValueExpr); ok {
+					c.key(kv.Key)
+				}
+			}
+		}
+	}
+}
+
+// constString value of an expression, if it is a constant string.
+func (c *checker) constString(e ast.Expr) (string, bool) {
+	tv, ok := c.pass.TypesInfo.Types[e]
+	if !ok || tv.Value == nil || tv.Value.Kind() != constant.String {
+		return "", false
+	}
+	return constant.StringVal(tv.Value), true
+}
+
+// printf call with a format and arguments, which start at the format.
+func (c *checker) printf(call *ast.CallExpr, callArgs []ast.Expr, name string) {
+	format, ok := c.constString(callArgs[0])
+	args := len(callArgs) - 1
+	if !ok {
+		if args == 0 && call.Ellipsis == 0 {
+			c.pass.ReportRangef(callArgs[0], "non-constant format string in call to %s", name)
+		}
+		return
+	}
+	f := parseFormat(format)
+	switch {
+	case f.bad != "":
+		c.pass.ReportRangef(call, "%s format %q has %s", name, format, f.bad)
+	case f.wrap:
+		c.pass.ReportRangef(call, "%s does not support error-wrapping directive %%w", name)
+	case call.Ellipsis != 0 || f.indexed:
+		// The number of arguments is not known statically.
+	case len(f.verbs) == 0 && args > 0:
+		c.pass.ReportRangef(call, "%s call has arguments but no formatting directives", name)
+	case f.args != args:
+		c.pass.ReportRangef(call, "%s format %q reads %d %s, but call has %d %s",
+			name, format, f.args, plural(f.args, "arg", "args"), args, plural(args, "arg", "args"))
+	}
+}
+
+// print call with a message, which is not formatted, and the name of its printf variant.
+func (c *checker) print(call *ast.CallExpr, args []ast.Expr, name, printf string) {
+	m, ok := c.constString(args[0])
+	if !ok {
+		return
+	}
+	if f := parseFormat(m); len(f.verbs) > 0 {
+		c.pass.ReportRangef(call, "%s call has possible formatting directive %%%c, use %s", name, f.verbs[0], printf)
+	}
+}
+
+// plural form of a word for n.
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
+
+// key of a detail or label that may look like PHI.
+func (c *checker) key(e ast.Expr) {
+	k, ok := c.constString(e)
+	if ok && c.phi.match(k) {
+		c.pass.ReportRangef(e, "key %q looks like PHI, log an identifier instead", k)
+	}
+}
+
+// discarded child entries from a call used as a statement.
+func (c *checker) discarded(stmt *ast.ExprStmt) {
+	call, ok := ast.Unparen(stmt.X).(*ast.CallExpr)
+	if !ok {
+		return
+	}
+	fn := c.slogFunc(call)
+	if fn == nil || !returnsEntry(fn) {
+		return
+	}
+	if fn.Name() == "StartOperation" {
+		c.pass.ReportRangef(call, "result of StartOperation is discarded so EndOperation is never called")
+		return
+	}
+	c.pass.ReportRangef(call, "result of %s is discarded, it returns a child Entry and does not log", fn.Name())
+}
+
+// returnsEntry reports whether fn returns only an *Entry.
+func returnsEntry(fn *types.Func) bool {
+	res := fn.Type().(*types.Signature).Results()
+	return res.Len() == 1 && isEntry(res.At(0).Type())
+}
+
+// isEntry reports whether t is *slog.Entry.
+func isEntry(t types.Type) bool {
+	p, ok := t.(*types.Pointer)
+	if !ok {
+		return false
+	}
+	named, ok := p.Elem().(*types.Named)
+	return ok && named.Obj().Name() == "Entry" && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == slogPath
+}
+
+// operations started in a function body and assigned to a variable that never has EndOperation called.
+// Variables used other than to log, such as being returned or passed on, are assumed to be ended elsewhere.
+func (c *checker) operations(body *ast.BlockStmt) {
+	started := make(map[types.Object]*ast.CallExpr)
+	ast.Inspect(body, func(n ast.Node) bool {
+		assign, ok := n.(*ast.AssignStmt)
+		if !ok || len(assign.Lhs) != 1 || len(assign.Rhs) != 1 {
+			return true
+		}
+		call, ok := ast.Unparen(assign.Rhs[0]).(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		if fn := c.slogFunc(call); fn == nil || fn.Name() != "StartOperation" {
+			return true
+		}
+		if id, ok := assign.Lhs[0].(*ast.Ident); ok {
+			if obj := c.object(id); obj != nil {
+				started[obj] = call
+			}
+		}
+		return true
+	})
+	if len(started) == 0 {
+		return
+	}
+
+	ended := make(map[types.Object]bool)
+	receivers := make(map[*ast.Ident]bool)
+	ast.Inspect(body, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		sel, ok := ast.Unparen(call.Fun).(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		id, ok := ast.Unparen(sel.X).(*ast.Ident)
+		if !ok {
+			return true
+		}
+		obj := c.pass.TypesInfo.Uses[id]
+		if _, ok := started[obj]; !ok {
+			return true
+		}
+		fn := c.slogFunc(call)
+		switch {
+		case fn == nil:
+		case fn.Name() == "EndOperation":
+			ended[obj] = true
+			receivers[id] = true
+		case fn.Type().(*types.Signature).Results().Len() == 0:
+			receivers[id] = true
+		}
+		return true
+	})
+
+	escaped := make(map[types.Object]bool)
+	ast.Inspect(body, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok && !receivers[id] {
+			if obj := c.pass.TypesInfo.Uses[id]; obj != nil {
+				escaped[obj] = true
+			}
+		}
+		return true
+	})
+	for obj, call := range started {
+		if !ended[obj] && !escaped[obj] {
+			c.pass.ReportRangef(call, "EndOperation is never called for the operation started here")
+		}
+	}
+}
+
+// object defined or assigned by an identifier.
+func (c *checker) object(id *ast.Ident) types.Object {
+	if obj := c.pass.TypesInfo.Defs[id]; obj != nil {
+		return obj
+	}
+	return c.pass.TypesInfo.Uses[id]
+}
+
+// format directives parsed from a printf format.
+type format struct {
+	verbs   []rune
+	args    int
+	indexed bool
+	wrap    bool
+	bad     string
+}
+
+// parseFormat counting the arguments read by each directive.
+func parseFormat(s string) format {
+	var f format
+	for i := 0; i < len(s); i++ {
+		if s[i] != '%' {
+			continue
+		}
+		i++
+		for i < len(s) && strings.IndexByte("+-# 0", s[i]) >= 0 {
+			i++
+		}
+		if i < len(s) && s[i] == '[' {
+			f.indexed = true
+			return f
+		}
+		i = f.number(s, i)
+		if i < len(s) && s[i] == '.' {
+			i = f.number(s, i+1)
+		}
+		if i >= len(s) {
+			f.bad = "a missing verb at the end"
+			return f
+		}
+		verb, size := utf8.DecodeRuneInString(s[i:])
+		i += size - 1
+		if verb == '%' {
+			continue
+		}
+		if verb == 'w' {
+			f.wrap = true
+		}
+		f.verbs = append(f.verbs, verb)
+		f.args++
+	}
+	return f
+}
+
+// number of a width or precision, where * reads an argument.
+func (f *format) number(s string, i int) int {
+	if i < len(s) && s[i] == '*' {
+		f.args++
+		return i + 1
+	}
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+	return i
+}
@@ -0,0 +1,34 @@
+package slogcheck
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestAnalyzer(t *testing.T) {
+	if err := Analyzer.Flags.Set("phi", "colour"); err != nil {
+		t.Fatal(err)
+	}
+	defer Analyzer.Flags.Set("phi", "")
+	analysistest.Run(t, analysistest.TestData(), Analyzer, "a")
+}
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		format string
+		args   int
+	}{
+		{"", 0},
+		{"%%", 0},
+		{"%s %d", 2},
+		{"%-10.3f", 1},
+		{"%*.*f", 3},
+		{"%+q %#x", 2},
+	}
+	for _, tt := range tests {
+		if got := parseFormat(tt.format); got.args != tt.args {
+			t.Errorf("unexpected args for %q\nwant: %d\ngot: %d", tt.format, tt.args, got.args)
+		}
+	}
+}
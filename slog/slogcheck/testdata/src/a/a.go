@@ -0,0 +1,82 @@
+package a
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ParticleHealth/tau/slog"
+)
+
+func printf(id string, n int, args []interface{}) {
+	slog.Infof("fetched %s", id)
+	slog.Infof("fetched %d of %s", n, id)
+	slog.Infof("%*d", 3, n)
+	slog.Infof("%[1]s %[1]s", id)
+	slog.Infof("%d%%", n)
+	slog.Infof("%s", args...)
+	slog.Infof("fetched", id) // want `Infof call has arguments but no formatting directives`
+	slog.Warnf("fetched")
+	slog.Infof("fetched %s %d", id)                   // want `Infof format "fetched %s %d" reads 2 args, but call has 1 arg`
+	slog.Infof("fetched %s", id, n)                   // want `Infof format "fetched %s" reads 1 arg, but call has 2 args`
+	slog.Infof("fetched %")                           // want `Infof format "fetched %" has a missing verb at the end`
+	slog.WithError(nil).Errorf("%w", errors.New("x")) // want `Errorf does not support error-wrapping directive %w`
+	slog.Infof(id)                                    // want `non-constant format string in call to Infof`
+	slog.Infof(fmt.Sprint(id), n)
+}
+
+func ctx(ctx context.Context, id string) {
+	slog.InfofCtx(ctx, "fetched %s", id)
+	slog.InfofCtx(ctx, "fetched %s %s", id) // want `InfofCtx format "fetched %s %s" reads 2 args, but call has 1 arg`
+	slog.InfoCtx(ctx, "fetched", id)
+	slog.InfoCtx(ctx, "fetched %s") // want `InfoCtx call has possible formatting directive %s, use InfofCtx`
+}
+
+func print(id string) {
+	slog.Info("fetched " + id)
+	slog.Info("100%")
+	slog.Info("fetched %s")                      // want `Info call has possible formatting directive %s, use Infof`
+	slog.WithDetail("id", id).Error("failed %v") // want `Error call has possible formatting directive %v, use Errorf`
+}
+
+func discarded(e *slog.Entry) {
+	slog.WithDetail("id", 1)              // want `result of WithDetail is discarded, it returns a child Entry and does not log`
+	e.WithError(errors.New("x"))          // want `result of WithError is discarded, it returns a child Entry and does not log`
+	slog.StartOperation("op", "producer") // want `result of StartOperation is discarded so EndOperation is never called`
+	e = e.WithDetail("id", 1)
+	e.WithDetail("id", 1).Info("logged")
+	e.Info("logged")
+}
+
+func operations() *slog.Entry {
+	ended := slog.StartOperation("op", "producer")
+	defer ended.EndOperation()
+	ended.Info("working")
+
+	never := slog.StartOperation("op", "producer") // want `EndOperation is never called for the operation started here`
+	never.Info("working")
+
+	returned := slog.StartOperation("op", "producer")
+	go func() {
+		closure := slog.StartOperation("op", "producer")
+		defer closure.EndOperation()
+	}()
+	return returned
+}
+
+func phi(e *slog.Entry, name string) {
+	e.WithDetail("patient_id", 1).Info("x")
+	e.WithDetail("ip_address", 1).Info("x")
+	e.WithDetail("patientName", name).Info("x") // want `key "patientName" looks like PHI, log an identifier instead`
+	e.WithDetail("DOB", name).Info("x")         // want `key "DOB" looks like PHI, log an identifier instead`
+	slog.String("first-name", name)             // want `key "first-name" looks like PHI, log an identifier instead`
+	slog.Time("date_of_birth", time.Time{})     // want `key "date_of_birth" looks like PHI, log an identifier instead`
+	slog.Label("diagnosis_code", name)          // want `key "diagnosis_code" looks like PHI, log an identifier instead`
+	e.WithDetails(slog.Fields{
+		"count": 1,
+		"ssn":   name, // want `key "ssn" looks like PHI, log an identifier instead`
+	}).Info("x")
+	slog.WithLabels(slog.Fields{"email": name}).Info("x") // want `key "email" looks like PHI, log an identifier instead`
+	e.WithDetail("favourite_colour", name).Info("x")      // want `key "favourite_colour" looks like PHI, log an identifier instead`
+}
@@ -0,0 +1,39 @@
+// Package slog is a stub of the slog API used by the analyzer tests.
+package slog
+
+import (
+	"context"
+	"time"
+)
+
+type Fields map[string]interface{}
+
+type Field struct{}
+
+type Entry struct{}
+
+func (e *Entry) Info(m string)                                      {}
+func (e *Entry) Infof(format string, v ...interface{})              {}
+func (e *Entry) Error(m string)                                     {}
+func (e *Entry) Errorf(format string, v ...interface{})             {}
+func (e *Entry) WithDetail(k string, v interface{}) *Entry          { return e }
+func (e *Entry) WithDetails(details Fields) *Entry                  { return e }
+func (e *Entry) WithLabels(labels Fields) *Entry                    { return e }
+func (e *Entry) WithError(err error) *Entry                         { return e }
+func (e *Entry) With(fields ...Field) *Entry                        { return e }
+func (e *Entry) StartOperation(id, producer string) *Entry          { return e }
+func (e *Entry) EndOperation()                                      {}
+func Info(m string)                                                 {}
+func Infof(format string, v ...interface{})                         {}
+func InfoCtx(ctx context.Context, v ...interface{})                 {}
+func InfofCtx(ctx context.Context, format string, v ...interface{}) {}
+func Warn(m string)                                                 {}
+func Warnf(format string, v ...interface{})                         {}
+func WithDetail(k string, v interface{}) *Entry                     { return nil }
+func WithDetails(details Fields) *Entry                             { return nil }
+func WithLabels(labels Fields) *Entry                               { return nil }
+func WithError(err error) *Entry                                    { return nil }
+func StartOperation(id, producer string) *Entry                     { return nil }
+func String(k, v string) Field                                      { return Field{} }
+func Time(k string, v time.Time) Field                              { return Field{} }
+func Label(k, v string) Field                                       { return Field{} }
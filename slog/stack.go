@@ -0,0 +1,107 @@
+package slog
+
+import (
+	"runtime"
+	"strings"
+)
+
+// DefaultStackDepth of stacks captured unless changed with SetStackDepth.
+const DefaultStackDepth = 16
+
+// Function prefixes that can be passed to SetStackFilters.
+const (
+	FilterRuntime = "runtime."
+	FilterTesting = "testing."
+	FilterSlog    = "github.com/ParticleHealth/tau/slog."
+)
+
+// stackSlack frames captured beyond the depth when filters are set, so filtered frames do not shorten the stack.
+const stackSlack = 16
+
+// stackConfig for a Logger, guarded by its mutex.
+type stackConfig struct {
+	n       int
+	min     Severity
+	filters []string
+}
+
+// depth of stacks, falling back to the default.
+func (c stackConfig) depth() int {
+	if c.n <= 0 {
+		return DefaultStackDepth
+	}
+	return c.n
+}
+
+// severity from which stacks are captured, falling back to Error.
+func (c stackConfig) severity() Severity {
+	if c.min == SeverityDefault {
+		return SeverityError
+	}
+	return c.min
+}
+
+// filtered reports whether a function should be left out of stacks.
+func (c stackConfig) filtered(function string) bool {
+	for _, prefix := range c.filters {
+		if strings.HasPrefix(function, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// stackConfig of the logger at the time of the call.
+func (l *Logger) stackConfig() stackConfig {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.stack
+}
+
+// callers of the calling function, skipping skip frames as for runtime.Callers.
+func (c stackConfig) callers(skip int) stack {
+	n := c.depth()
+	if len(c.filters) > 0 {
+		n += stackSlack
+	}
+	pcs := make([]uintptr, n)
+	return pcs[:runtime.Callers(skip, pcs)]
+}
+
+// SetStackDepth of stacks included with entries, defaults to 16 frames.
+func (l *Logger) SetStackDepth(depth int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.stack.n = depth
+}
+
+// SetStackDepth of stacks included with entries by the package-level logger, defaults to 16 frames.
+func SetStackDepth(depth int) {
+	std.SetStackDepth(depth)
+}
+
+// SetStackSeverity from which stacks are included with entries, defaults to Error.
+func (l *Logger) SetStackSeverity(min Severity) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.stack.min = min
+}
+
+// SetStackSeverity from which stacks are included with entries by the package-level logger, defaults to Error.
+func SetStackSeverity(min Severity) {
+	std.SetStackSeverity(min)
+}
+
+// SetStackFilters of function prefixes left out of stacks, such as FilterRuntime.
+// The first frame is always kept so Error Reporting groups errors consistently.
+func (l *Logger) SetStackFilters(prefixes ...string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.stack.filters = prefixes
+}
+
+// SetStackFilters of function prefixes left out of stacks by the package-level logger, such as FilterRuntime.
+// The first frame is always kept so Error Reporting groups errors consistently.
+func SetStackFilters(prefixes ...string) {
+	std.SetStackFilters(prefixes...)
+}
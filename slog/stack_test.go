@@ -0,0 +1,107 @@
+package slog
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// frames of the stack trace in a single encoded entry.
+func frames(t *testing.T, out *bytes.Buffer) []string {
+	t.Helper()
+	var e Entry
+	if err := json.Unmarshal(out.Bytes(), &e); err != nil {
+		t.Fatalf("decoding entry: %v\n%s", err, out)
+	}
+	out.Reset()
+	if e.StackTrace == "" {
+		return nil
+	}
+	var fns []string
+	// Skip the error and goroutine header, then every frame is a function followed by its location.
+	lines := strings.Split(strings.TrimSuffix(e.StackTrace, "\n"), "\n")[3:]
+	for i := 0; i < len(lines); i += 2 {
+		fns = append(fns, lines[i])
+	}
+	return fns
+}
+
+func recurse(n int, f func()) {
+	if n == 0 {
+		f()
+		return
+	}
+	recurse(n-1, f)
+}
+
+func TestStackDepth(t *testing.T) {
+	out := bytes.NewBuffer(make([]byte, 0, 4096))
+	logger := newLogger(out)
+	e := logger.entry()
+
+	recurse(40, func() { e.Error("deep") })
+	if got := frames(t, out); len(got) != DefaultStackDepth {
+		t.Errorf("unexpected default depth\nwant: %d\ngot: %d", DefaultStackDepth, len(got))
+	}
+
+	logger.SetStackDepth(32)
+	recurse(40, func() { e.Error("deep") })
+	if got := frames(t, out); len(got) != 32 {
+		t.Errorf("unexpected depth\nwant: 32\ngot: %d", len(got))
+	}
+
+	logger.SetStackDepth(2)
+	e.WithStack().Info("shallow")
+	got := frames(t, out)
+	if len(got) != 2 || !strings.HasPrefix(got[0], "github.com/ParticleHealth/tau/slog.TestStackDepth(") {
+		t.Errorf("unexpected frames: %v", got)
+	}
+}
+
+func TestStackFilters(t *testing.T) {
+	out := bytes.NewBuffer(make([]byte, 0, 4096))
+	logger := newLogger(out)
+	e := logger.entry()
+	logger.SetStackFilters(FilterRuntime, FilterTesting)
+
+	e.Error("filtered")
+	got := frames(t, out)
+	if len(got) == 0 || !strings.HasPrefix(got[0], "github.com/ParticleHealth/tau/slog.TestStackFilters(") {
+		t.Fatalf("unexpected first frame: %v", got)
+	}
+	for _, fn := range got {
+		if strings.HasPrefix(fn, FilterRuntime) || strings.HasPrefix(fn, FilterTesting) {
+			t.Errorf("frame not filtered: %s", fn)
+		}
+	}
+
+	// The first frame is kept for grouping even when it matches a filter.
+	logger.SetStackFilters(FilterSlog, FilterTesting, FilterRuntime)
+	e.Error("first")
+	got = frames(t, out)
+	if len(got) != 1 || !strings.HasPrefix(got[0], "github.com/ParticleHealth/tau/slog.TestStackFilters(") {
+		t.Errorf("unexpected frames: %v", got)
+	}
+}
+
+func TestStackSeverity(t *testing.T) {
+	out := bytes.NewBuffer(make([]byte, 0, 4096))
+	logger := newLogger(out)
+	e := logger.entry()
+
+	e.Warn("no stack")
+	if got := frames(t, out); got != nil {
+		t.Errorf("stack included below Error: %v", got)
+	}
+
+	logger.SetStackSeverity(SeverityWarning)
+	e.Warn("stack")
+	if got := frames(t, out); len(got) == 0 || !strings.HasPrefix(got[0], "github.com/ParticleHealth/tau/slog.TestStackSeverity(") {
+		t.Errorf("unexpected frames: %v", got)
+	}
+	e.Info("no stack")
+	if got := frames(t, out); got != nil {
+		t.Errorf("stack included below Warning: %v", got)
+	}
+}
@@ -0,0 +1,300 @@
+package slog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Syslog facilities, see RFC 5424 section 6.2.1.
+const (
+	FacilityUser   = 1
+	FacilityDaemon = 3
+	FacilityLocal0 = 16
+	FacilityLocal1 = 17
+	FacilityLocal2 = 18
+	FacilityLocal3 = 19
+	FacilityLocal4 = 20
+	FacilityLocal5 = 21
+	FacilityLocal6 = 22
+	FacilityLocal7 = 23
+)
+
+// DefaultEnterpriseID used in structured data IDs, reserved for documentation by RFC 5612.
+const DefaultEnterpriseID = 32473
+
+// syslogSockets tried in order when no network is configured.
+var syslogSockets = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+// syslogSeverity of a severity, Default is treated as Info.
+func syslogSeverity(s Severity) int {
+	switch {
+	case s >= SeverityEmergency:
+		return 0
+	case s >= SeverityAlert:
+		return 1
+	case s >= SeverityCritical:
+		return 2
+	case s >= SeverityError:
+		return 3
+	case s >= SeverityWarning:
+		return 4
+	case s >= SeverityNotice:
+		return 5
+	case s >= SeverityInfo || s == SeverityDefault:
+		return 6
+	}
+	return 7
+}
+
+// SyslogConfig for a Sink writing RFC 5424 messages to a syslog server.
+type SyslogConfig struct {
+	// Network and Address of the server, such as "udp" and "10.0.0.1:514", "tcp" or "unix".
+	// Defaults to the local syslog socket.
+	Network string
+	Address string
+	// Facility of messages, defaults to FacilityUser.
+	Facility int
+	// AppName and Hostname of messages, default to the executable name and host name.
+	AppName  string
+	Hostname string
+	// EnterpriseID for structured data IDs, defaults to DefaultEnterpriseID.
+	EnterpriseID int
+	// QueueSize of messages waiting to be written, any more are dropped.
+	// Messages are written synchronously if zero.
+	QueueSize int
+}
+
+// NewSyslogSink writing RFC 5424 messages to a syslog server.
+// Labels, details and trace are carried as structured data. Streams use octet counting framing per RFC 6587.
+func NewSyslogSink(cfg SyslogConfig) (*Sink, error) {
+	w, err := newSyslogWriter(cfg)
+	if err != nil {
+		return nil, err
+	}
+	var s *Sink
+	if cfg.QueueSize > 0 {
+		s = NewAsyncSink(w, cfg.QueueSize)
+	} else {
+		s = NewSink(w)
+	}
+	s.owned = true
+	s.SetEncoder(NewSyslogEncoder(cfg))
+	return s, nil
+}
+
+// NewSyslogEncoder writing RFC 5424 messages without framing.
+func NewSyslogEncoder(cfg SyslogConfig) Encoder {
+	if cfg.Facility == 0 {
+		cfg.Facility = FacilityUser
+	}
+	if cfg.AppName == "" {
+		cfg.AppName = filepath.Base(os.Args[0])
+	}
+	if cfg.Hostname == "" {
+		cfg.Hostname, _ = os.Hostname()
+	}
+	if cfg.EnterpriseID == 0 {
+		cfg.EnterpriseID = DefaultEnterpriseID
+	}
+	app := syslogHeader(cfg.AppName, 48)
+	host := syslogHeader(cfg.Hostname, 255)
+	pid := strconv.Itoa(os.Getpid())
+	pen := "@" + strconv.Itoa(cfg.EnterpriseID)
+	return EncoderFunc(func(buf *bytes.Buffer, e *Entry) error {
+		fmt.Fprintf(buf, "<%d>1 %s %s %s %s - ",
+			cfg.Facility*8+syslogSeverity(e.Severity),
+			time.Now().Format("2006-01-02T15:04:05.000000Z07:00"), host, app, pid)
+
+		sd := buf.Len()
+		if len(e.Labels) > 0 {
+			params := make(map[string]string, len(e.Labels))
+			for k, v := range e.Labels {
+				params[k] = v
+			}
+			writeStructuredData(buf, "labels"+pen, params)
+		}
+		details := e.mergedDetails()
+		if len(details) > 0 || e.Err != "" {
+			params := make(map[string]string, len(details)+1)
+			for k, v := range details {
+				params[k] = detailString(e.detail(v))
+			}
+			if e.Err != "" {
+				params["error"] = e.Err
+			}
+			writeStructuredData(buf, "details"+pen, params)
+		}
+		if e.Trace != "" {
+			writeStructuredData(buf, "trace"+pen, map[string]string{
+				"trace_id": e.traceID(),
+				"span_id":  e.SpanID,
+				"sampled":  strconv.FormatBool(e.TraceSampled),
+			})
+		}
+		if s := e.SourceLocation; s != nil {
+			writeStructuredData(buf, "source"+pen, map[string]string{"file": s.File, "line": s.Line, "function": s.Function})
+		}
+		if buf.Len() == sd {
+			buf.WriteByte('-')
+		}
+
+		buf.WriteByte(' ')
+		buf.WriteString(e.Message)
+		if e.StackTrace != "" {
+			buf.WriteByte('\n')
+			buf.WriteString(e.StackTrace)
+		}
+		return nil
+	})
+}
+
+// syslogHeader field limited to printable ASCII of at most max characters, "-" if empty.
+func syslogHeader(v string, max int) string {
+	b := make([]byte, 0, len(v))
+	for i := 0; i < len(v) && len(b) < max; i++ {
+		if v[i] > ' ' && v[i] < 127 {
+			b = append(b, v[i])
+		}
+	}
+	if len(b) == 0 {
+		return "-"
+	}
+	return string(b)
+}
+
+// sdName limited to the characters allowed in structured data names.
+func sdName(v string) string {
+	b := make([]byte, 0, len(v))
+	for i := 0; i < len(v) && len(b) < 32; i++ {
+		c := v[i]
+		if c <= ' ' || c >= 127 || c == '=' || c == ']' || c == '"' {
+			c = '_'
+		}
+		b = append(b, c)
+	}
+	if len(b) == 0 {
+		return "_"
+	}
+	return string(b)
+}
+
+// sdEscaper of structured data parameter values.
+var sdEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
+
+// writeStructuredData element with params sorted by name.
+func writeStructuredData(buf *bytes.Buffer, id string, params map[string]string) {
+	names := make([]string, 0, len(params))
+	for k := range params {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	buf.WriteByte('[')
+	buf.WriteString(id)
+	for _, k := range names {
+		buf.WriteByte(' ')
+		buf.WriteString(sdName(k))
+		buf.WriteString(`="`)
+		_, _ = sdEscaper.WriteString(buf, params[k])
+		buf.WriteByte('"')
+	}
+	buf.WriteByte(']')
+}
+
+// detailString of a normalized detail value, strings as is and anything else as JSON.
+func detailString(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+// syslogWriter sends one message per Write, reconnecting once if the connection was lost.
+type syslogWriter struct {
+	network string
+	address string
+	stream  bool
+
+	mu   sync.Mutex // guards conn
+	conn net.Conn
+}
+
+// newSyslogWriter connected to the configured server or the local socket.
+func newSyslogWriter(cfg SyslogConfig) (*syslogWriter, error) {
+	w := &syslogWriter{network: cfg.Network, address: cfg.Address}
+	if w.network == "" {
+		for _, path := range syslogSockets {
+			for _, network := range []string{"unixgram", "unix"} {
+				conn, err := net.Dial(network, path)
+				if err == nil {
+					w.network, w.address, w.conn = network, path, conn
+					w.stream = network == "unix"
+					return w, nil
+				}
+			}
+		}
+		return nil, errors.New("no local syslog socket found")
+	}
+	switch w.network {
+	case "tcp", "tcp4", "tcp6", "unix":
+		w.stream = true
+	}
+	conn, err := net.Dial(w.network, w.address)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to syslog: %w", err)
+	}
+	w.conn = conn
+	return w, nil
+}
+
+// Write a single message, framed with its length on streams.
+func (w *syslogWriter) Write(p []byte) (int, error) {
+	msg := p
+	if w.stream {
+		msg = append(strconv.AppendInt(make([]byte, 0, len(p)+8), int64(len(p)), 10), ' ')
+		msg = append(msg, p...)
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.conn != nil {
+		if _, err := w.conn.Write(msg); err == nil {
+			return len(p), nil
+		}
+		_ = w.conn.Close()
+		w.conn = nil
+	}
+	conn, err := net.Dial(w.network, w.address)
+	if err != nil {
+		return 0, fmt.Errorf("reconnecting to syslog: %w", err)
+	}
+	w.conn = conn
+	if _, err := conn.Write(msg); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Close the connection.
+func (w *syslogWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
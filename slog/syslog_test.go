@@ -0,0 +1,154 @@
+package slog
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// syslogPattern of an RFC 5424 message, capturing the priority, structured data and message.
+var syslogPattern = regexp.MustCompile(`^<(\d+)>1 \S+ host app \d+ - (-|(?:\[.*?[^\\]\])+) ((?s).*)$`)
+
+func syslogLogger(t *testing.T, network, address string) (*Logger, *Sink) {
+	t.Helper()
+	sink, err := NewSyslogSink(SyslogConfig{Network: network, Address: address, Facility: FacilityLocal0, AppName: "app", Hostname: "host"})
+	if err != nil {
+		t.Fatalf("creating sink: %v", err)
+	}
+	logger := New(sink)
+	logger.SetIncludeSources(false)
+	return logger, sink
+}
+
+func TestSyslogSinkDatagram(t *testing.T) {
+	for _, network := range []string{"udp", "unixgram"} {
+		t.Run(network, func(t *testing.T) {
+			address := "127.0.0.1:0"
+			if network == "unixgram" {
+				address = filepath.Join(t.TempDir(), "log")
+			}
+			conn, err := net.ListenPacket(network, address)
+			if err != nil {
+				t.Fatalf("listening: %v", err)
+			}
+			defer conn.Close()
+			logger, sink := syslogLogger(t, network, conn.LocalAddr().String())
+			defer sink.Close()
+
+			e := logger.entry()
+			e.Trace = "projects/p/traces/4bf92f3577b34da6a3ce929d0e0e4736"
+			e.SpanID = "00f067aa0ba902b7"
+			e.WithLabels(Fields{"service": "records"}).WithDetail("quote", `a "b" ] \`).WithDetail("version", version{1, 2}).With(Int("count", 2)).Warn("warned")
+
+			_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+			msg := make([]byte, 4096)
+			n, _, err := conn.ReadFrom(msg)
+			if err != nil {
+				t.Fatalf("reading message: %v", err)
+			}
+			m := syslogPattern.FindStringSubmatch(string(msg[:n]))
+			if m == nil {
+				t.Fatalf("not an RFC 5424 message: %s", msg[:n])
+			}
+			if m[1] != strconv.Itoa(FacilityLocal0*8+4) {
+				t.Errorf("unexpected priority\nwant: %d\ngot: %s", FacilityLocal0*8+4, m[1])
+			}
+			wantSD := `[labels@32473 service="records"]` +
+				`[details@32473 count="2" quote="a \"b\" \] \\" version="{}"]` +
+				`[trace@32473 sampled="false" span_id="00f067aa0ba902b7" trace_id="4bf92f3577b34da6a3ce929d0e0e4736"]`
+			if m[2] != wantSD {
+				t.Errorf("unexpected structured data\nwant: %s\ngot: %s", wantSD, m[2])
+			}
+			if m[3] != "warned" {
+				t.Errorf("unexpected message: %s", m[3])
+			}
+		})
+	}
+}
+
+func TestSyslogSinkStream(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listening: %v", err)
+	}
+	defer ln.Close()
+	received := make(chan string, 10)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				for {
+					size, err := r.ReadString(' ')
+					if err != nil {
+						return
+					}
+					n, _ := strconv.Atoi(strings.TrimSpace(size))
+					msg := make([]byte, n)
+					if _, err := io.ReadFull(r, msg); err != nil {
+						return
+					}
+					received <- string(msg)
+				}
+			}()
+		}
+	}()
+
+	logger, sink := syslogLogger(t, "tcp", ln.Addr().String())
+	defer sink.Close()
+	e := logger.entry()
+	e.WithError(errors.New("failed")).Error("with stack")
+	e.Debug("plain")
+
+	for _, want := range []struct {
+		pri     int
+		sd      string
+		message string
+	}{
+		{FacilityLocal0*8 + 3, `[details@32473 error="failed"]`, "with stack\nfailed:\n\ngoroutine 0 [???]:\n"},
+		{FacilityLocal0*8 + 7, "-", "plain"},
+	} {
+		select {
+		case msg := <-received:
+			m := syslogPattern.FindStringSubmatch(msg)
+			if m == nil {
+				t.Fatalf("not an RFC 5424 message: %q", msg)
+			}
+			if m[1] != strconv.Itoa(want.pri) || m[2] != want.sd || !strings.HasPrefix(m[3], want.message) {
+				t.Errorf("unexpected message\nwant: %d %s %q\ngot: %s %s %q", want.pri, want.sd, want.message, m[1], m[2], m[3])
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("message not received")
+		}
+	}
+
+	// Closing the sink closes the connection, so the reader sees EOF.
+	if err := sink.Close(); err != nil {
+		t.Errorf("closing sink: %v", err)
+	}
+}
+
+func TestSyslogSeverity(t *testing.T) {
+	for s, want := range map[Severity]int{
+		SeverityDefault: 6, SeverityDebug: 7, SeverityInfo: 6, SeverityNotice: 5, SeverityWarning: 4,
+		SeverityError: 3, SeverityCritical: 2, SeverityAlert: 1, SeverityEmergency: 0,
+	} {
+		if got := syslogSeverity(s); got != want {
+			t.Errorf("unexpected syslog severity for %s\nwant: %d\ngot: %d", s, want, got)
+		}
+	}
+	if _, err := NewSyslogSink(SyslogConfig{Network: "tcp", Address: "127.0.0.1:1"}); err == nil {
+		t.Error("expected error connecting to closed port")
+	}
+}
@@ -0,0 +1,201 @@
+package slog
+
+import (
+	"path"
+	"reflect"
+	"runtime"
+	"runtime/debug"
+	"strings"
+)
+
+// trimmer of source paths and function names, shared by all sources cached for a Logger setting.
+type trimmer struct {
+	module   bool
+	main     string
+	mainPkg  string
+	deps     map[string]string // module paths to versions
+	goroot   string
+	prefixes []string
+}
+
+// newTrimmer from build info, which may be nil when not available.
+func newTrimmer(module bool, prefixes []string, bi *debug.BuildInfo) *trimmer {
+	if !module && len(prefixes) == 0 {
+		return nil
+	}
+	t := &trimmer{module: module, prefixes: prefixes}
+	if module {
+		t.goroot = gorootSrc()
+		if bi != nil {
+			t.main = bi.Main.Path
+			t.mainPkg = bi.Path
+			t.deps = make(map[string]string, len(bi.Deps))
+			for _, d := range bi.Deps {
+				if d.Replace != nil {
+					d = d.Replace
+				}
+				t.deps[d.Path] = d.Version
+			}
+		}
+	}
+	return t
+}
+
+// gorootSrc directory that standard library source files are compiled from, found from the file of a runtime
+// function as GOROOT may differ from where the binary was built. Empty if built with -trimpath.
+func gorootSrc() string {
+	fn := runtime.FuncForPC(reflect.ValueOf(runtime.Callers).Pointer())
+	if fn == nil {
+		return ""
+	}
+	file, _ := fn.FileLine(fn.Entry())
+	i := strings.LastIndex(file, "/runtime/")
+	if i < 0 || !strings.HasSuffix(file[:i], "/src") {
+		return ""
+	}
+	return file[:i+1]
+}
+
+// dropSources cached for a trimmer that has been replaced.
+func dropSources(t *trimmer) {
+	if t == nil {
+		return
+	}
+	sourceMu.Lock()
+	defer sourceMu.Unlock()
+	for k := range sources {
+		if k.trim == t {
+			delete(sources, k)
+		}
+	}
+}
+
+// trim a source location in place.
+func (t *trimmer) trim(s *SourceLocation) {
+	if t == nil {
+		return
+	}
+	file, function := s.File, s.Function
+	if t.module {
+		file, function = t.trimModule(file, function)
+	}
+	for _, prefix := range t.prefixes {
+		if strings.HasPrefix(file, prefix) {
+			file = file[len(prefix):]
+			break
+		}
+	}
+	for _, prefix := range t.prefixes {
+		if strings.HasPrefix(function, prefix) {
+			function = function[len(prefix):]
+			break
+		}
+	}
+	s.File, s.Function = file, function
+}
+
+// trimModule to a path relative to the main module, path@version for dependencies,
+// or relative to GOROOT for the standard library. Function names are only shortened for the main module.
+func (t *trimmer) trimModule(file, function string) (string, string) {
+	if t.goroot != "" && strings.HasPrefix(file, t.goroot) {
+		return file[len(t.goroot):], function
+	}
+	// Functions in package main are named for the package rather than its import path.
+	qualified := function
+	if strings.HasPrefix(function, "main.") && t.mainPkg != "" {
+		qualified = t.mainPkg + function[len("main"):]
+	}
+	mod, version := t.moduleOf(qualified)
+	if mod == "" {
+		return file, function
+	}
+	dir := packageDir(qualified[len(mod):])
+	name := path.Base(file)
+	if mod == t.main {
+		if qualified != function {
+			return strings.TrimPrefix(path.Join(dir, name), "/"), function
+		}
+		rest := function[len(mod):]
+		if strings.HasPrefix(rest, ".") {
+			rest = path.Base(mod) + rest
+		}
+		return strings.TrimPrefix(path.Join(dir, name), "/"), strings.TrimPrefix(rest, "/")
+	}
+	if version != "" {
+		mod += "@" + version
+	}
+	return path.Join(mod, dir, name), function
+}
+
+// moduleOf a function name with its version, the longest matching path wins.
+func (t *trimmer) moduleOf(function string) (string, string) {
+	var mod, version string
+	match := func(p, v string) {
+		if len(p) > len(mod) && strings.HasPrefix(function, p) && len(function) > len(p) &&
+			(function[len(p)] == '/' || function[len(p)] == '.') {
+			mod, version = p, v
+		}
+	}
+	if t.main != "" {
+		match(t.main, "")
+	}
+	for p, v := range t.deps {
+		match(p, v)
+	}
+	return mod, version
+}
+
+// packageDir of a function name relative to its module, such as "/sub/pkg.Func" to "/sub/pkg".
+func packageDir(rest string) string {
+	i := strings.LastIndex(rest, "/")
+	if i < 0 {
+		return ""
+	}
+	if j := strings.Index(rest[i:], "."); j >= 0 {
+		return rest[:i+j]
+	}
+	return rest
+}
+
+// buildInfo of the running binary, nil if it was built without module support.
+func buildInfo() *debug.BuildInfo {
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return nil
+	}
+	return bi
+}
+
+// SetTrimModulePaths of source locations to be relative to the main module using build info.
+// Dependencies are given as module@version and the standard library relative to GOROOT.
+func (l *Logger) SetTrimModulePaths(trim bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var prefixes []string
+	if l.trim != nil {
+		prefixes = l.trim.prefixes
+	}
+	old := l.trim
+	l.trim = newTrimmer(trim, prefixes, buildInfo())
+	dropSources(old)
+}
+
+// SetTrimModulePaths of source locations for the package-level logger to be relative to the main module.
+func SetTrimModulePaths(trim bool) {
+	std.SetTrimModulePaths(trim)
+}
+
+// SetTrimPrefixes removed from source files and function names, the first matching prefix is removed.
+func (l *Logger) SetTrimPrefixes(prefixes ...string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	module := l.trim != nil && l.trim.module
+	old := l.trim
+	l.trim = newTrimmer(module, prefixes, buildInfo())
+	dropSources(old)
+}
+
+// SetTrimPrefixes removed from source files and function names for the package-level logger.
+func SetTrimPrefixes(prefixes ...string) {
+	std.SetTrimPrefixes(prefixes...)
+}
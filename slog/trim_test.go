@@ -0,0 +1,125 @@
+package slog
+
+import (
+	"bytes"
+	"encoding/json"
+	"runtime/debug"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTrimmer(t *testing.T) {
+	bi := &debug.BuildInfo{
+		Path: "github.com/ParticleHealth/tau/cmd/tau",
+		Main: debug.Module{Path: "github.com/ParticleHealth/tau"},
+		Deps: []*debug.Module{
+			{Path: "go.opencensus.io", Version: "v0.24.0"},
+			{Path: "gopkg.in/yaml.v3", Version: "v3.0.1"},
+			{Path: "example.com/old", Version: "v1.0.0", Replace: &debug.Module{Path: "example.com/new", Version: "v1.1.0"}},
+		},
+	}
+	goroot := gorootSrc()
+	tests := []struct {
+		name     string
+		module   bool
+		prefixes []string
+		in       SourceLocation
+		want     SourceLocation
+	}{
+		{
+			"main module", true, nil,
+			SourceLocation{File: "/home/runner/work/tau/slog/slog.go", Function: "github.com/ParticleHealth/tau/slog.(*Logger).log"},
+			SourceLocation{File: "slog/slog.go", Function: "slog.(*Logger).log"},
+		},
+		{
+			"main package", true, nil,
+			SourceLocation{File: "/home/runner/work/tau/cmd/tau/main.go", Function: "main.main"},
+			SourceLocation{File: "cmd/tau/main.go", Function: "main.main"},
+		},
+		{
+			"module root", true, nil,
+			SourceLocation{File: "/home/runner/work/tau/tau.go", Function: "github.com/ParticleHealth/tau.Run"},
+			SourceLocation{File: "tau.go", Function: "tau.Run"},
+		},
+		{
+			"dependency", true, nil,
+			SourceLocation{File: "/home/runner/go/pkg/mod/go.opencensus.io@v0.24.0/trace/trace.go", Function: "go.opencensus.io/trace.StartSpan"},
+			SourceLocation{File: "go.opencensus.io@v0.24.0/trace/trace.go", Function: "go.opencensus.io/trace.StartSpan"},
+		},
+		{
+			"dotted module", true, nil,
+			SourceLocation{File: "/home/runner/go/pkg/mod/gopkg.in/yaml.v3@v3.0.1/decode.go", Function: "gopkg.in/yaml.v3.Unmarshal"},
+			SourceLocation{File: "gopkg.in/yaml.v3@v3.0.1/decode.go", Function: "gopkg.in/yaml.v3.Unmarshal"},
+		},
+		{
+			"replaced", true, nil,
+			SourceLocation{File: "/tmp/new/pkg/x.go", Function: "example.com/new/pkg.X"},
+			SourceLocation{File: "example.com/new@v1.1.0/pkg/x.go", Function: "example.com/new/pkg.X"},
+		},
+		{
+			"standard library", true, nil,
+			SourceLocation{File: goroot + "net/http/server.go", Function: "net/http.(*conn).serve"},
+			SourceLocation{File: "net/http/server.go", Function: "net/http.(*conn).serve"},
+		},
+		{
+			"unknown", true, nil,
+			SourceLocation{File: "/src/x.go", Function: "example.com/unknown.X"},
+			SourceLocation{File: "/src/x.go", Function: "example.com/unknown.X"},
+		},
+		{
+			"prefixes", false, []string{"/other/", "/home/runner/work/", "github.com/ParticleHealth/"},
+			SourceLocation{File: "/home/runner/work/tau/slog/slog.go", Function: "github.com/ParticleHealth/tau/slog.Info"},
+			SourceLocation{File: "tau/slog/slog.go", Function: "tau/slog.Info"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.in
+			newTrimmer(tt.module, tt.prefixes, bi).trim(&got)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("unexpected source:\n%s", diff)
+			}
+		})
+	}
+	if newTrimmer(false, nil, bi) != nil {
+		t.Error("trimmer created without options")
+	}
+}
+
+func TestTrimModulePaths(t *testing.T) {
+	out := bytes.NewBuffer(make([]byte, 0, 1024))
+	logger := newLogger(out)
+	logger.SetIncludeSources(true)
+	logger.SetTrimModulePaths(true)
+	e := logger.entry()
+	e.Info("trimmed")
+
+	var got Entry
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("decoding entry: %v\n%s", err, out)
+	}
+	want := &SourceLocation{File: "slog/trim_test.go", Line: got.SourceLocation.Line, Function: "slog.TestTrimModulePaths"}
+	if diff := cmp.Diff(want, got.SourceLocation); diff != "" {
+		t.Errorf("unexpected source:\n%s", diff)
+	}
+
+	// Sources cached before trimming was changed are not reused.
+	out.Reset()
+	old := logger.trim
+	logger.SetTrimModulePaths(false)
+	sourceMu.RLock()
+	for k := range sources {
+		if k.trim == old {
+			t.Errorf("source cached for replaced trimmer: %+v", sources[k].source)
+		}
+	}
+	sourceMu.RUnlock()
+	e.Info("untrimmed")
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("decoding entry: %v\n%s", err, out)
+	}
+	if got.SourceLocation.Function != "github.com/ParticleHealth/tau/slog.TestTrimModulePaths" {
+		t.Errorf("trimming not disabled: %+v", got.SourceLocation)
+	}
+}